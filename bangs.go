@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/frort/ksk/internal/bang"
+)
+
+// runBangs implements "ksk bangs list|add".
+func runBangs(args []string) error {
+	usage := fmt.Errorf("usage: ksk bangs list | ksk bangs add [-name NAME] [-engine ENGINE] TRIGGER [URL]")
+	if len(args) == 0 {
+		return usage
+	}
+
+	db, err := bang.Load()
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "list", "ls":
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, b := range db.List() {
+			src := ""
+			if db.IsUser(b.Trigger) {
+				src = "(user)"
+			}
+			fmt.Fprintf(w, "!%s\t%s\t%s\t%s\n", b.Trigger, b.Name, b.Target(), src)
+		}
+		return w.Flush()
+
+	case "add":
+		fs := flag.NewFlagSet("bangs add", flag.ContinueOnError)
+		name := fs.String("name", "", "display name")
+		engine := fs.String("engine", "", "route to a ksk engine instead of opening a URL")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 || len(rest) > 2 || (len(rest) == 2) == (*engine != "") {
+			return fmt.Errorf("usage: ksk bangs add [-name NAME] TRIGGER URL | ksk bangs add [-name NAME] -engine ENGINE TRIGGER")
+		}
+		b := bang.Bang{Trigger: rest[0], Name: *name, Engine: *engine}
+		if len(rest) == 2 {
+			b.URL = rest[1]
+		}
+		if err := db.Add(b); err != nil {
+			return err
+		}
+		path, _ := bang.Path()
+		fmt.Printf("Added !%s to %s\n", b.Trigger, path)
+		return nil
+	}
+
+	return usage
+}
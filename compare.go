@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/frort/ksk/internal/config"
+	"github.com/frort/ksk/internal/index"
+	"github.com/frort/ksk/internal/search"
+	"github.com/frort/ksk/internal/tui"
+)
+
+// runCompare shows the results of query on each of the comma-separated
+// engines side by side.
+func runCompare(cfg *config.Config, keys *tui.KeyMap, engines string, opts search.Options, showAds bool, query string, idx *index.Index) error {
+	if strings.TrimSpace(query) == "" {
+		return errors.New("-compare needs a query")
+	}
+	var backends []search.Backend
+	for _, name := range strings.Split(engines, ",") {
+		b, err := search.New(strings.TrimSpace(name), opts)
+		if err != nil {
+			return err
+		}
+		backends = append(backends, b)
+	}
+	if len(backends) < 2 {
+		return errors.New("-compare needs at least two engines")
+	}
+
+	var recorder tui.Recorder
+	if idx != nil {
+		recorder = &index.Recorder{Index: idx, FetchPages: cfg.Index.FetchPages}
+	}
+	m := tui.NewCompareModel(query, backends, tui.Options{
+		Sanitizer:    newSanitizer(cfg.CleanURLs),
+		ShowAds:      showAds,
+		SnippetLines: cfg.SnippetLines,
+		ListLayout:   cfg.ListLayout,
+		Keys:         keys,
+		Region:       opts.Region,
+		Language:     opts.Language,
+		SafeSearch:   opts.SafeSearch,
+		Recorder:     recorder,
+	})
+	_, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+	closeIndex(idx)
+	return err
+}
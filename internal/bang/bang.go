@@ -0,0 +1,219 @@
+package bang
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/frort/ksk/internal/config"
+	"github.com/frort/ksk/internal/search"
+)
+
+// Placeholder is replaced with the escaped query in URL templates.
+const Placeholder = "{{{s}}}"
+
+// Bang is a "!trigger" shortcut that either opens a site search in the
+// browser (URL) or routes the query to another ksk engine (Engine).
+type Bang struct {
+	Trigger string `json:"trigger"`
+	Name    string `json:"name"`
+	URL     string `json:"url,omitempty"`
+	Engine  string `json:"engine,omitempty"`
+}
+
+var builtin = []Bang{
+	{Trigger: "ddg", Name: "DuckDuckGo (ksk)", Engine: "duckduckgo"},
+	{Trigger: "b", Name: "Brave Search (ksk)", Engine: "brave"},
+	{Trigger: "brave", Name: "Brave Search (ksk)", Engine: "brave"},
+	{Trigger: "g", Name: "Google", URL: "https://www.google.com/search?q={{{s}}}"},
+	{Trigger: "w", Name: "Wikipedia", URL: "https://en.wikipedia.org/w/index.php?search={{{s}}}"},
+	{Trigger: "wja", Name: "Wikipedia (ja)", URL: "https://ja.wikipedia.org/w/index.php?search={{{s}}}"},
+	{Trigger: "wt", Name: "Wiktionary", URL: "https://en.wiktionary.org/w/index.php?search={{{s}}}"},
+	{Trigger: "gh", Name: "GitHub", URL: "https://github.com/search?q={{{s}}}"},
+	{Trigger: "gl", Name: "GitLab", URL: "https://gitlab.com/search?search={{{s}}}"},
+	{Trigger: "mdn", Name: "MDN Web Docs", URL: "https://developer.mozilla.org/en-US/search?q={{{s}}}"},
+	{Trigger: "so", Name: "Stack Overflow", URL: "https://stackoverflow.com/search?q={{{s}}}"},
+	{Trigger: "go", Name: "pkg.go.dev", URL: "https://pkg.go.dev/search?q={{{s}}}"},
+	{Trigger: "npm", Name: "npm", URL: "https://www.npmjs.com/search?q={{{s}}}"},
+	{Trigger: "pypi", Name: "PyPI", URL: "https://pypi.org/search/?q={{{s}}}"},
+	{Trigger: "crates", Name: "crates.io", URL: "https://crates.io/search?q={{{s}}}"},
+	{Trigger: "aw", Name: "ArchWiki", URL: "https://wiki.archlinux.org/index.php?search={{{s}}}"},
+	{Trigger: "nixpkgs", Name: "Nix packages", URL: "https://search.nixos.org/packages?query={{{s}}}"},
+	{Trigger: "yt", Name: "YouTube", URL: "https://www.youtube.com/results?search_query={{{s}}}"},
+	{Trigger: "r", Name: "Reddit", URL: "https://www.reddit.com/search/?q={{{s}}}"},
+	{Trigger: "hn", Name: "Hacker News", URL: "https://hn.algolia.com/?q={{{s}}}"},
+	{Trigger: "osm", Name: "OpenStreetMap", URL: "https://www.openstreetmap.org/search?query={{{s}}}"},
+	{Trigger: "imdb", Name: "IMDb", URL: "https://www.imdb.com/find/?q={{{s}}}"},
+	{Trigger: "a", Name: "Amazon", URL: "https://www.amazon.com/s?k={{{s}}}"},
+	{Trigger: "man", Name: "Arch manual pages", URL: "https://man.archlinux.org/search?q={{{s}}}"},
+	{Trigger: "deepl", Name: "DeepL", URL: "https://www.deepl.com/translator#auto/en/{{{s}}}"},
+}
+
+// DB holds the bundled bangs merged with the user's bangs.json.
+type DB struct {
+	bangs map[string]Bang
+	user  []Bang
+}
+
+// Path returns the location of the user bang file.
+func Path() (string, error) {
+	d, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(d, "bangs.json"), nil
+}
+
+// Load returns the bundled bangs overlaid with user-defined ones.
+func Load() (*DB, error) {
+	db := &DB{bangs: make(map[string]Bang)}
+	for _, b := range builtin {
+		db.bangs[b.Trigger] = b
+	}
+
+	path, err := Path()
+	if err != nil {
+		return db, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return db, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading bangs: %w", err)
+	}
+	if err := json.Unmarshal(data, &db.user); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	for i, b := range db.user {
+		b.Trigger = normalize(b.Trigger)
+		db.user[i] = b
+		db.bangs[b.Trigger] = b
+	}
+	return db, nil
+}
+
+// normalize returns trigger as it is stored: lowercase, without the "!".
+func normalize(trigger string) string {
+	return strings.ToLower(strings.TrimPrefix(trigger, "!"))
+}
+
+// Lookup finds the bang for trigger (without the leading "!").
+func (db *DB) Lookup(trigger string) (Bang, bool) {
+	b, ok := db.bangs[normalize(trigger)]
+	return b, ok
+}
+
+// List returns all bangs sorted by trigger.
+func (db *DB) List() []Bang {
+	list := make([]Bang, 0, len(db.bangs))
+	for _, b := range db.bangs {
+		list = append(list, b)
+	}
+	slices.SortFunc(list, func(a, b Bang) int { return strings.Compare(a.Trigger, b.Trigger) })
+	return list
+}
+
+// IsUser reports whether trigger is defined in the user's bangs.json.
+func (db *DB) IsUser(trigger string) bool {
+	trigger = normalize(trigger)
+	return slices.ContainsFunc(db.user, func(b Bang) bool { return b.Trigger == trigger })
+}
+
+// Add validates b and saves it to the user's bangs.json, replacing any
+// existing user bang with the same trigger.
+func (db *DB) Add(b Bang) error {
+	b.Trigger = normalize(b.Trigger)
+	if b.Trigger == "" || strings.ContainsAny(b.Trigger, " \t!") {
+		return fmt.Errorf("invalid trigger %q", b.Trigger)
+	}
+	if (b.URL == "") == (b.Engine == "") {
+		return fmt.Errorf("bang needs either a URL template or an engine")
+	}
+	if b.Engine != "" {
+		if _, err := search.New(b.Engine, search.Options{}); err != nil {
+			return err
+		}
+	}
+	if b.URL != "" {
+		if !strings.Contains(b.URL, Placeholder) {
+			return fmt.Errorf("URL template must contain %s", Placeholder)
+		}
+		if u, err := url.Parse(strings.ReplaceAll(b.URL, Placeholder, "x")); err != nil || u.Host == "" {
+			return fmt.Errorf("invalid URL template %q", b.URL)
+		}
+	}
+	if b.Name == "" {
+		b.Name = b.Trigger
+	}
+
+	db.user = slices.DeleteFunc(db.user, func(u Bang) bool { return u.Trigger == b.Trigger })
+	db.user = append(db.user, b)
+	db.bangs[b.Trigger] = b
+
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(db.user, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Parse splits a "!trigger" off the start or end of query, as DuckDuckGo
+// does. ok is false when the query contains no bang.
+func Parse(query string) (trigger, rest string, ok bool) {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "", "", false
+	}
+	if t, found := strings.CutPrefix(fields[0], "!"); found && t != "" {
+		return t, strings.Join(fields[1:], " "), true
+	}
+	last := len(fields) - 1
+	if t, found := strings.CutPrefix(fields[last], "!"); found && t != "" {
+		return t, strings.Join(fields[:last], " "), true
+	}
+	return "", "", false
+}
+
+// Resolve looks up the bang in query. It returns the bang and the query
+// with the bang removed.
+func (db *DB) Resolve(query string) (Bang, string, bool) {
+	trigger, rest, ok := Parse(query)
+	if !ok {
+		return Bang{}, "", false
+	}
+	b, ok := db.Lookup(trigger)
+	return b, rest, ok
+}
+
+// Expand fills the URL template with query. An empty query opens the site's
+// front page.
+func (b Bang) Expand(query string) string {
+	if query == "" {
+		if u, err := url.Parse(strings.ReplaceAll(b.URL, Placeholder, "")); err == nil {
+			return u.Scheme + "://" + u.Host + "/"
+		}
+	}
+	return strings.ReplaceAll(b.URL, Placeholder, url.QueryEscape(query))
+}
+
+// Target describes where the bang goes, for listings.
+func (b Bang) Target() string {
+	if b.Engine != "" {
+		return "engine:" + b.Engine
+	}
+	return b.URL
+}
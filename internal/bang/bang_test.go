@@ -0,0 +1,57 @@
+package bang
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadLowercasesUserTriggers(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("KSK_CONFIG_DIR", dir)
+	data := `[{"trigger": "GoDoc", "name": "Go docs", "url": "https://go.dev/s/{{{s}}}"}, {"trigger": "!DDG", "engine": "brave"}]`
+	if err := os.WriteFile(filepath.Join(dir, "bangs.json"), []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, trigger := range []string{"godoc", "GODOC", "GoDoc"} {
+		if b, ok := db.Lookup(trigger); !ok || b.Name != "Go docs" {
+			t.Errorf("Lookup(%q) = %+v, %v", trigger, b, ok)
+		}
+	}
+	if b, _ := db.Lookup("ddg"); b.Engine != "brave" {
+		t.Errorf("user !DDG did not override the built-in ddg: %+v", b)
+	}
+	if !db.IsUser("GoDoc") || !db.IsUser("ddg") {
+		t.Error("IsUser does not match the lowercased triggers")
+	}
+}
+
+func TestAddLowercasesTrigger(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("KSK_CONFIG_DIR", dir)
+	db, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, trigger := range []string{"!MyWiki", "mywiki"} {
+		if err := db.Add(Bang{Trigger: trigger, URL: "https://wiki.example/?q={{{s}}}"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(db.user) != 1 || db.user[0].Trigger != "mywiki" {
+		t.Errorf("user bangs = %+v, want one mywiki", db.user)
+	}
+
+	db, err = Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := db.Lookup("MYWIKI"); !ok {
+		t.Error("saved bang not found after reload")
+	}
+}
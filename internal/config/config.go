@@ -0,0 +1,151 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/frort/ksk/internal/search"
+)
+
+// Config is the user configuration read from config.json.
+type Config struct {
+	Engine       string    `json:"engine,omitempty"`
+	Region       string    `json:"region,omitempty"`
+	Language     string    `json:"language,omitempty"` // BCP 47, e.g. ja or pt-BR
+	ShowAds      bool      `json:"show_ads,omitempty"`
+	SnippetLines int       `json:"snippet_lines,omitempty"` // default 2
+	ListLayout   bool      `json:"list_layout,omitempty"`   // one line per result
+	SafeSearch   string    `json:"safe_search,omitempty"`   // off, moderate or strict
+	CleanURLs    CleanURLs `json:"clean_urls"`
+	Plugins      []Plugin  `json:"plugins,omitempty"`
+	// Scrapers declares HTML engines by CSS selectors.
+	Scrapers []search.ScraperConfig `json:"scrapers,omitempty"`
+	Index    Index                  `json:"index"`
+	// ResumeSession restores the last session when ksk starts without a
+	// query, and saves it on exit.
+	ResumeSession bool `json:"resume_session,omitempty"`
+	// Theme names a built-in theme or one of Themes; default auto.
+	Theme  string           `json:"theme,omitempty"`
+	Themes map[string]Theme `json:"themes,omitempty"`
+	// Compact draws results without boxes, fitting more on screen.
+	Compact bool `json:"compact,omitempty"`
+	// Keys rebinds TUI actions, e.g. "down": ["j", "ctrl+j"]. An empty list
+	// unbinds the action.
+	Keys map[string][]string `json:"keys,omitempty"`
+	// SuggestEndpoints replaces the completion endpoint of an engine, e.g.
+	// "duckduckgo": "http://localhost:8080/ac" for a local stub server.
+	SuggestEndpoints map[string]string `json:"suggest_endpoints,omitempty"`
+	// CharLimit caps the length of a query; default 256, -1 for no limit.
+	CharLimit int `json:"char_limit,omitempty"`
+	// Editor is the command Alt+E opens the query in; default $VISUAL or
+	// $EDITOR.
+	Editor string `json:"editor,omitempty"`
+}
+
+// Theme is a user-defined color theme. Colors are "#rrggbb" or an ANSI
+// color number (0-255); unset colors come from Base.
+type Theme struct {
+	Base      string `json:"base,omitempty"` // built-in theme, default dark
+	Primary   string `json:"primary,omitempty"`
+	Highlight string `json:"highlight,omitempty"`
+	Muted     string `json:"muted,omitempty"`
+	URL       string `json:"url,omitempty"`
+	Snippet   string `json:"snippet,omitempty"`
+	Error     string `json:"error,omitempty"`
+	Border    string `json:"border,omitempty"`
+	Selection string `json:"selection,omitempty"` // background of the selected result
+}
+
+// Index controls the local full-text index of seen results.
+type Index struct {
+	Disable bool `json:"disable,omitempty"`
+	// FetchPages also indexes the text of pages opened from ksk.
+	FetchPages    bool `json:"fetch_pages,omitempty"`
+	RetentionDays int  `json:"retention_days,omitempty"` // default 365
+	MaxEntries    int  `json:"max_entries,omitempty"`    // default 20000
+}
+
+// CleanURLs controls URL sanitizing before open/copy.
+type CleanURLs struct {
+	Disable bool `json:"disable,omitempty"`
+	// StripParams replaces the built-in tracking parameter list when set.
+	StripParams []string `json:"strip_params,omitempty"`
+	// ExtraStripParams is appended to the active list.
+	ExtraStripParams []string `json:"extra_strip_params,omitempty"`
+	// Frontends maps hosts to privacy frontends, e.g. "youtube.com": "yewtu.be".
+	Frontends map[string]string `json:"frontends,omitempty"`
+}
+
+// Plugin is an external executable used as a search engine.
+type Plugin struct {
+	Name    string         `json:"name"`
+	Aliases []string       `json:"aliases,omitempty"`
+	Command string         `json:"command"`
+	Args    []string       `json:"args,omitempty"`
+	Timeout string         `json:"timeout,omitempty"` // Go duration, e.g. "15s"
+	Options map[string]any `json:"options,omitempty"`
+}
+
+// Dir returns the ksk configuration directory.
+func Dir() (string, error) {
+	if d := os.Getenv("KSK_CONFIG_DIR"); d != "" {
+		return d, nil
+	}
+	d, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(d, "ksk"), nil
+}
+
+// DataDir returns the directory for ksk's persistent data (index, sessions):
+// $XDG_DATA_HOME/ksk, ~/.local/share/ksk on Unix, or the config directory
+// elsewhere.
+func DataDir() (string, error) {
+	if d := os.Getenv("KSK_DATA_DIR"); d != "" {
+		return d, nil
+	}
+	if d := os.Getenv("XDG_DATA_HOME"); d != "" {
+		return filepath.Join(d, "ksk"), nil
+	}
+	if runtime.GOOS != "windows" && runtime.GOOS != "darwin" {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, ".local", "share", "ksk"), nil
+		}
+	}
+	return Dir()
+}
+
+// Path returns the path of config.json.
+func Path() (string, error) {
+	d, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(d, "config.json"), nil
+}
+
+// Load reads config.json. A missing file yields the zero Config.
+func Load() (*Config, error) {
+	cfg := &Config{}
+	path, err := Path()
+	if err != nil {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return cfg, nil
+}
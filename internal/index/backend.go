@@ -0,0 +1,156 @@
+package index
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/frort/ksk/internal/search"
+)
+
+// EngineName is the engine name under which the index is searchable.
+const EngineName = "local"
+
+const pageSize = 10
+
+// Backend searches the local index as a ksk engine.
+type Backend struct {
+	Index *Index
+}
+
+func (b *Backend) Name() string { return EngineName }
+
+func (b *Backend) Search(query string) (*search.Page, error) {
+	return b.page(query, 1), nil
+}
+
+func (b *Backend) NextPage(prev *search.Page, query string) (*search.Page, error) {
+	if !prev.HasMore {
+		return nil, fmt.Errorf("no more pages")
+	}
+	return b.page(query, prev.PageNum+1), nil
+}
+
+func (b *Backend) PrevPage(query string, pageNum int) (*search.Page, error) {
+	return b.page(query, max(1, pageNum)), nil
+}
+
+func (b *Backend) page(query string, pageNum int) *search.Page {
+	hits := b.Index.Search(query)
+	start := min((pageNum-1)*pageSize, len(hits))
+	end := min(start+pageSize, len(hits))
+
+	page := &search.Page{PageNum: pageNum, HasMore: end < len(hits)}
+	for _, h := range hits[start:end] {
+		e := h.Entry
+		snippet := e.Snippet
+		if snippet == "" {
+			snippet = excerpt(e.Text, query)
+		}
+		page.Results = append(page.Results, search.Result{
+			Title:   e.Title,
+			URL:     e.URL,
+			Snippet: snippet,
+			Meta: []search.Field{
+				{Name: "Seen", Value: e.Seen.Format("2006-01-02")},
+				{Name: "Engine", Value: e.Engine},
+				{Name: "Query", Value: strings.Join(e.Queries, " | ")},
+			},
+		})
+	}
+	return page
+}
+
+// excerptLen is the length, in runes, of excerpts cut from page text.
+const excerptLen = 200
+
+// excerpt returns the part of text around the first query term found.
+func excerpt(text, query string) string {
+	lower := strings.ToLower(text)
+	runes := []rune(text)
+	start := 0
+	for _, t := range Tokenize(query) {
+		if i := strings.Index(lower, t); i >= 0 {
+			start = max(0, utf8.RuneCountInString(lower[:i])-excerptLen/4)
+			break
+		}
+	}
+	start = min(start, len(runes))
+	end := min(start+excerptLen, len(runes))
+	return strings.TrimSpace(string(runes[start:end]))
+}
+
+// maxPageBytes caps how much of a fetched page is read.
+const maxPageBytes = 2 << 20
+
+// maxTextRunes caps how much page text is stored per entry.
+const maxTextRunes = 20000
+
+var fetchClient = &http.Client{Timeout: 15 * time.Second}
+
+// FetchText downloads url and returns its visible text.
+func FetchText(url string) (string, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64; rv:138.0) Gecko/20100101 Firefox/138.0")
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.8")
+
+	resp, err := fetchClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fetching %s: status %d", url, resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "" && !strings.Contains(ct, "html") && !strings.HasPrefix(ct, "text/") {
+		return "", fmt.Errorf("fetching %s: unsupported content type %s", url, ct)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(io.LimitReader(resp.Body, maxPageBytes))
+	if err != nil {
+		return "", err
+	}
+	doc.Find("script, style, noscript, nav, header, footer, svg").Remove()
+	text := strings.Join(strings.Fields(doc.Find("body").Text()), " ")
+	if r := []rune(text); len(r) > maxTextRunes {
+		text = string(r[:maxTextRunes])
+	}
+	return text, nil
+}
+
+// Recorder feeds results seen in the TUI into an index.
+type Recorder struct {
+	Index *Index
+	// FetchPages indexes the text of opened pages as well.
+	FetchPages bool
+}
+
+// Record indexes results returned for query. Results from the local engine
+// itself are skipped.
+func (r *Recorder) Record(query, engine string, results []search.Result) error {
+	if engine == EngineName {
+		return nil
+	}
+	return r.Index.Add(query, engine, results)
+}
+
+// RecordOpen fetches and indexes the text of an opened page when
+// FetchPages is set.
+func (r *Recorder) RecordOpen(url string) error {
+	if !r.FetchPages {
+		return nil
+	}
+	text, err := FetchText(url)
+	if err != nil {
+		return err
+	}
+	return r.Index.SetText(url, text)
+}
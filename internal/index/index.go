@@ -0,0 +1,393 @@
+package index
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/frort/ksk/internal/config"
+	"github.com/frort/ksk/internal/search"
+)
+
+const (
+	DefaultRetention  = 365 * 24 * time.Hour
+	DefaultMaxEntries = 20000
+)
+
+// Entry is one indexed result.
+type Entry struct {
+	URL     string    `json:"url"`
+	Title   string    `json:"title"`
+	Snippet string    `json:"snippet,omitempty"`
+	Text    string    `json:"text,omitempty"` // fetched page text, if any
+	Queries []string  `json:"queries,omitempty"`
+	Engine  string    `json:"engine"`
+	Seen    time.Time `json:"seen"`
+}
+
+// Hit is a ranked search match. Entry is a copy, unaffected by later
+// changes to the index.
+type Hit struct {
+	Entry *Entry
+	Score float64
+}
+
+// posting records how often a term occurs in one entry, per field.
+type posting struct {
+	entry int
+	tf    float64 // field-weighted term frequency
+}
+
+// Field weights for ranking: a match in the title counts more than one in
+// fetched page text.
+const (
+	weightTitle   = 3
+	weightQuery   = 2
+	weightURL     = 1.5
+	weightSnippet = 1
+	weightText    = 0.5
+)
+
+// BM25 parameters
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// flushInterval bounds how often changes are written to the index file.
+// Close writes whatever is left.
+const flushInterval = 30 * time.Second
+
+// Index is an in-memory inverted index over entries persisted as JSON. The
+// file is read on first use. It is safe for concurrent use.
+type Index struct {
+	mu      sync.Mutex
+	path    string
+	loaded  bool
+	dirty   bool      // entries changed since the last save
+	stale   bool      // postings lag behind entries
+	saved   time.Time // last save
+	entries []*Entry
+	byURL   map[string]int
+	terms   map[string][]posting
+	lengths []float64
+	avgLen  float64
+
+	Retention  time.Duration
+	MaxEntries int
+}
+
+// Path returns the default index file location.
+func Path() (string, error) {
+	d, err := config.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(d, "index.json"), nil
+}
+
+// Open returns the index stored at path. Nothing is read until the index
+// is first used; a missing file yields an empty index.
+func Open(path string) *Index {
+	return &Index{
+		path:       path,
+		Retention:  DefaultRetention,
+		MaxEntries: DefaultMaxEntries,
+	}
+}
+
+// load reads the index file unless that was done already. A file that does
+// not parse is moved aside, so that saving does not overwrite it, and the
+// index starts empty; the error is then only a warning. Callers hold mu.
+func (idx *Index) load() error {
+	if idx.loaded {
+		return nil
+	}
+	data, err := os.ReadFile(idx.path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("reading index: %w", err)
+	}
+	var warning error
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &idx.entries); err != nil {
+			bad := idx.path + ".bad"
+			if rerr := os.Rename(idx.path, bad); rerr != nil {
+				return fmt.Errorf("parsing %s: %w", idx.path, err)
+			}
+			idx.entries = nil
+			warning = fmt.Errorf("parsing %s: %w (moved to %s, starting a new index)", idx.path, err, bad)
+		}
+	}
+	idx.loaded = true
+	idx.rebuild()
+	return warning
+}
+
+// Len returns the number of indexed entries.
+func (idx *Index) Len() int {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.load()
+	return len(idx.entries)
+}
+
+// Add records results seen for query on engine. The index file is updated
+// at most every flushInterval.
+func (idx *Index) Add(query, engine string, results []search.Result) error {
+	if len(results) == 0 {
+		return nil
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	warning := idx.load()
+	if !idx.loaded {
+		return warning
+	}
+
+	now := time.Now()
+	for _, r := range results {
+		if r.IsAd() {
+			continue
+		}
+		if i, ok := idx.byURL[r.URL]; ok {
+			e := idx.entries[i]
+			e.Title = r.Title
+			if r.Snippet != "" {
+				e.Snippet = r.Snippet
+			}
+			if !slices.Contains(e.Queries, query) {
+				e.Queries = append(e.Queries, query)
+			}
+			e.Engine = engine
+			e.Seen = now
+			continue
+		}
+		idx.byURL[r.URL] = len(idx.entries)
+		idx.entries = append(idx.entries, &Entry{
+			URL:     r.URL,
+			Title:   r.Title,
+			Snippet: r.Snippet,
+			Queries: []string{query},
+			Engine:  engine,
+			Seen:    now,
+		})
+	}
+	return errors.Join(warning, idx.commit())
+}
+
+// SetText stores fetched page text for url, if it is indexed.
+func (idx *Index) SetText(url, text string) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	warning := idx.load()
+	i, ok := idx.byURL[url]
+	if !ok {
+		return warning
+	}
+	idx.entries[i].Text = text
+	return errors.Join(warning, idx.commit())
+}
+
+// Close writes changes that have not been saved yet.
+func (idx *Index) Close() error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if !idx.dirty {
+		return nil
+	}
+	return idx.save()
+}
+
+// commit applies retention and saves unless the last save was less than
+// flushInterval ago. The postings are rebuilt by the next search. Callers
+// hold mu.
+func (idx *Index) commit() error {
+	idx.prune(time.Now())
+	idx.byURL = make(map[string]int, len(idx.entries))
+	for i, e := range idx.entries {
+		idx.byURL[e.URL] = i
+	}
+	idx.stale = true
+	idx.dirty = true
+	if time.Since(idx.saved) < flushInterval {
+		return nil
+	}
+	return idx.save()
+}
+
+// prune drops entries older than Retention and the oldest entries beyond
+// MaxEntries.
+func (idx *Index) prune(now time.Time) {
+	if idx.Retention > 0 {
+		cutoff := now.Add(-idx.Retention)
+		idx.entries = slices.DeleteFunc(idx.entries, func(e *Entry) bool { return e.Seen.Before(cutoff) })
+	}
+	if idx.MaxEntries > 0 && len(idx.entries) > idx.MaxEntries {
+		slices.SortStableFunc(idx.entries, func(a, b *Entry) int { return a.Seen.Compare(b.Seen) })
+		idx.entries = idx.entries[len(idx.entries)-idx.MaxEntries:]
+	}
+}
+
+func (idx *Index) save() error {
+	if err := os.MkdirAll(filepath.Dir(idx.path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(idx.entries)
+	if err != nil {
+		return err
+	}
+	// Write to a temp file first so a crash never leaves a truncated index
+	tmp := idx.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, idx.path); err != nil {
+		return err
+	}
+	idx.dirty = false
+	idx.saved = time.Now()
+	return nil
+}
+
+// rebuild recomputes the URL map and postings from entries.
+func (idx *Index) rebuild() {
+	idx.byURL = make(map[string]int, len(idx.entries))
+	idx.terms = make(map[string][]posting)
+	idx.lengths = make([]float64, len(idx.entries))
+	total := 0.0
+
+	for i, e := range idx.entries {
+		idx.byURL[e.URL] = i
+		tf := make(map[string]float64)
+		length := 0.0
+		add := func(text string, weight float64) {
+			for _, t := range Tokenize(text) {
+				tf[t] += weight
+				length += weight
+			}
+		}
+		add(e.Title, weightTitle)
+		add(strings.Join(e.Queries, " "), weightQuery)
+		add(e.URL, weightURL)
+		add(e.Snippet, weightSnippet)
+		add(e.Text, weightText)
+
+		for t, f := range tf {
+			idx.terms[t] = append(idx.terms[t], posting{entry: i, tf: f})
+		}
+		idx.lengths[i] = length
+		total += length
+	}
+	if len(idx.entries) > 0 {
+		idx.avgLen = total / float64(len(idx.entries))
+	}
+	idx.stale = false
+}
+
+// Search ranks entries against query with BM25. Every query term must
+// match; more recent entries win ties.
+func (idx *Index) Search(query string) []Hit {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.load()
+	if idx.stale {
+		idx.rebuild()
+	}
+
+	terms := Tokenize(query)
+	if len(terms) == 0 {
+		return nil
+	}
+	slices.Sort(terms)
+	terms = slices.Compact(terms)
+
+	n := float64(len(idx.entries))
+	scores := make(map[int]float64)
+	matched := make(map[int]int)
+	for _, t := range terms {
+		postings := idx.terms[t]
+		if len(postings) == 0 {
+			return nil
+		}
+		idf := math.Log(1 + (n-float64(len(postings))+0.5)/(float64(len(postings))+0.5))
+		for _, p := range postings {
+			norm := bm25K1 * (1 - bm25B + bm25B*idx.lengths[p.entry]/idx.avgLen)
+			scores[p.entry] += idf * p.tf * (bm25K1 + 1) / (p.tf + norm)
+			matched[p.entry]++
+		}
+	}
+
+	var hits []Hit
+	for i, s := range scores {
+		if matched[i] == len(terms) {
+			e := *idx.entries[i]
+			e.Queries = slices.Clone(e.Queries)
+			hits = append(hits, Hit{Entry: &e, Score: s})
+		}
+	}
+	slices.SortFunc(hits, func(a, b Hit) int {
+		if a.Score != b.Score {
+			if a.Score > b.Score {
+				return -1
+			}
+			return 1
+		}
+		return b.Entry.Seen.Compare(a.Entry.Seen)
+	})
+	return hits
+}
+
+// Tokenize lowercases text and splits it into terms. Runs of CJK characters,
+// which are written without spaces, are split into overlapping bigrams.
+func Tokenize(text string) []string {
+	var tokens []string
+	var word, cjk []rune
+
+	flushWord := func() {
+		if len(word) > 0 {
+			tokens = append(tokens, string(word))
+			word = word[:0]
+		}
+	}
+	flushCJK := func() {
+		switch {
+		case len(cjk) == 1:
+			tokens = append(tokens, string(cjk))
+		case len(cjk) > 1:
+			for i := 0; i+1 < len(cjk); i++ {
+				tokens = append(tokens, string(cjk[i:i+2]))
+			}
+		}
+		cjk = cjk[:0]
+	}
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case isCJK(r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return tokens
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
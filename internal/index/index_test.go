@@ -0,0 +1,104 @@
+package index
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/frort/ksk/internal/search"
+)
+
+var goResults = []search.Result{{Title: "The Go Programming Language", URL: "https://go.dev/"}}
+
+func TestOpenIsLazy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.json")
+	idx := Open(path)
+
+	// Written after Open, read on first use
+	data, _ := json.Marshal([]*Entry{{URL: "https://go.dev/", Title: "Go", Engine: "brave"}})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if hits := idx.Search("go"); len(hits) != 1 {
+		t.Errorf("%d hits, want 1", len(hits))
+	}
+}
+
+func TestCorruptIndexIsMovedAside(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.json")
+	if err := os.WriteFile(path, []byte("[{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	idx := Open(path)
+	if err := idx.Add("golang", "brave", goResults); err == nil {
+		t.Error("corrupt index not reported")
+	}
+	if idx.Len() != 1 {
+		t.Errorf("Len = %d after Add, want 1", idx.Len())
+	}
+	if data, err := os.ReadFile(path + ".bad"); err != nil || string(data) != "[{" {
+		t.Errorf("corrupt file not kept: %q, %v", data, err)
+	}
+	// Later additions work without warnings
+	if err := idx.Add("go", "brave", []search.Result{{Title: "Go Tour", URL: "https://go.dev/tour/"}}); err != nil {
+		t.Errorf("Add = %v", err)
+	}
+}
+
+func TestAddFlushesPeriodically(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.json")
+	idx := Open(path)
+	if err := idx.Add("golang", "brave", goResults); err != nil {
+		t.Fatal(err)
+	}
+	if Open(path).Len() != 1 {
+		t.Fatal("first Add not saved")
+	}
+
+	// Within flushInterval of the last save, changes stay in memory
+	if err := idx.Add("go tour", "brave", []search.Result{{Title: "A Tour of Go", URL: "https://go.dev/tour/"}}); err != nil {
+		t.Fatal(err)
+	}
+	if n := Open(path).Len(); n != 1 {
+		t.Errorf("saved %d entries before Close, want 1", n)
+	}
+	if hits := idx.Search("tour"); len(hits) != 1 {
+		t.Errorf("unsaved entry not searchable: %d hits", len(hits))
+	}
+	if err := idx.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := Open(path).Len(); n != 2 {
+		t.Errorf("saved %d entries after Close, want 2", n)
+	}
+}
+
+func TestConcurrentRecordAndSearch(t *testing.T) {
+	idx := Open(filepath.Join(t.TempDir(), "index.json"))
+	rec := &Recorder{Index: idx}
+	b := &Backend{Index: idx}
+	if err := rec.Record("golang", "brave", goResults); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range 200 {
+			rec.Record(fmt.Sprint("go ", i), "duckduckgo", goResults)
+		}
+	}()
+	for range 200 {
+		if _, err := b.Search("go"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-done
+
+	page, err := b.Search("go")
+	if err != nil || len(page.Results) != 1 {
+		t.Fatalf("Search = %v, %v", page, err)
+	}
+}
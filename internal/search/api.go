@@ -0,0 +1,79 @@
+package search
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// apiClient is shared by the JSON API backends, which need no cookies.
+var apiClient = &http.Client{Timeout: 20 * time.Second}
+
+const apiUserAgent = "ksk (+https://github.com/frort/ksk)"
+
+// getJSON fetches endpoint with params and decodes the response into v.
+func getJSON(endpoint string, params url.Values, header http.Header, v any) error {
+	req, err := http.NewRequest("GET", endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header = header.Clone()
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+	req.Header.Set("User-Agent", apiUserAgent)
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "application/json")
+	}
+
+	resp, err := apiClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("performing search: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusForbidden {
+		return fmt.Errorf("rate limit triggered (status %d) — try again later", resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("search returned status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("parsing response: %w", err)
+	}
+	return nil
+}
+
+// htmlText converts an HTML fragment (API snippets with <span> highlights
+// and entities) to plain text.
+func htmlText(fragment string) string {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(fragment))
+	if err != nil {
+		return fragment
+	}
+	return strings.Join(strings.Fields(doc.Text()), " ")
+}
+
+// shortDate trims an RFC 3339 timestamp to its date.
+func shortDate(ts string) string {
+	if t, err := time.Parse(time.RFC3339, ts); err == nil {
+		return t.Format("2006-01-02")
+	}
+	return ts
+}
+
+// humanCount formats large counts as 1.2k / 3.4M.
+func humanCount(n int) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1_000)
+	}
+	return fmt.Sprintf("%d", n)
+}
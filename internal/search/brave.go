@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/PuerkitoBio/goquery"
@@ -18,7 +19,12 @@ func init() {
 }
 
 type Brave struct {
-	Region string // e.g. "jp", "us", "de"
+	Region     string // e.g. "jp", "us", "de"
+	Language   string // BCP 47 tag sent as Accept-Language and search_lang
+	SafeSearch SafeSearch
+	// SuggestEndpoint overrides the completion endpoint, e.g. to point at a
+	// local stub server.
+	SuggestEndpoint string
 }
 
 const braveEndpoint = "https://search.brave.com/search"
@@ -32,7 +38,15 @@ var braveHeaders = http.Header{
 func (b *Brave) Name() string { return "brave" }
 
 func (b *Brave) Search(query string) (*Page, error) {
-	return braveDoSearch(query, 0, 1, b.Region)
+	return b.doSearch(query, 0, 1, nil)
+}
+
+// braveLiteral turns off Brave's spellcheck.
+var braveLiteral = url.Values{"spellcheck": {"0"}}
+
+// SearchLiteral searches query with Brave's spellcheck disabled.
+func (b *Brave) SearchLiteral(query string) (*Page, error) {
+	return b.doSearch(query, 0, 1, braveLiteral)
 }
 
 func (b *Brave) NextPage(prev *Page, query string) (*Page, error) {
@@ -40,23 +54,41 @@ func (b *Brave) NextPage(prev *Page, query string) (*Page, error) {
 		return nil, fmt.Errorf("no more pages")
 	}
 	// Brave's offset parameter is a 0-indexed page number
-	return braveDoSearch(query, prev.PageNum, prev.PageNum+1, b.Region)
+	return b.doSearch(query, prev.PageNum, prev.PageNum+1, prev.NextParams)
 }
 
 func (b *Brave) PrevPage(query string, pageNum int) (*Page, error) {
 	if pageNum <= 1 {
 		return b.Search(query)
 	}
-	return braveDoSearch(query, pageNum-1, pageNum, b.Region)
+	return b.doSearch(query, pageNum-1, pageNum, nil)
+}
+
+func (b *Brave) PrevPageLiteral(query string, pageNum int) (*Page, error) {
+	if pageNum <= 1 {
+		return b.SearchLiteral(query)
+	}
+	return b.doSearch(query, pageNum-1, pageNum, braveLiteral)
 }
 
-func braveDoSearch(query string, offset, pageNum int, region string) (*Page, error) {
+// doSearch fetches one results page. extra is added to the request and
+// carried over to the next page (e.g. spellcheck=0).
+func (b *Brave) doSearch(query string, offset, pageNum int, extra url.Values) (*Page, error) {
 	params := url.Values{
 		"q":      {query},
 		"source": {"web"},
 	}
-	if region != "" {
-		params.Set("country", braveRegion(region))
+	for k, v := range extra {
+		params[k] = v
+	}
+	if c := braveRegion(b.Region); c != "" {
+		params.Set("country", c)
+	}
+	if l := braveLanguage(b.Language); l != "" {
+		params.Set("search_lang", l)
+	}
+	if b.SafeSearch != "" {
+		params.Set("safesearch", string(b.SafeSearch))
 	}
 	if offset > 0 {
 		params.Set("offset", fmt.Sprintf("%d", offset))
@@ -66,7 +98,7 @@ func braveDoSearch(query string, offset, pageNum int, region string) (*Page, err
 	if err != nil {
 		return nil, fmt.Errorf("creating request: %w", err)
 	}
-	req.Header = braveHeaders.Clone()
+	req.Header = b.headers()
 
 	resp, err := braveClient.Do(req)
 	if err != nil {
@@ -74,7 +106,7 @@ func braveDoSearch(query string, offset, pageNum int, region string) (*Page, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == 503 {
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
 		return nil, fmt.Errorf("rate limit triggered (status %d) — try again later", resp.StatusCode)
 	}
 	if resp.StatusCode != http.StatusOK {
@@ -86,7 +118,7 @@ func braveDoSearch(query string, offset, pageNum int, region string) (*Page, err
 		return nil, fmt.Errorf("parsing response: %w", err)
 	}
 
-	page := &Page{PageNum: pageNum}
+	page := &Page{PageNum: pageNum, NextParams: extra, Literal: extra.Get("spellcheck") == "0"}
 
 	// Main results: div.snippet[data-type="web"]
 	doc.Find(`div.snippet[data-type="web"]`).Each(func(i int, s *goquery.Selection) {
@@ -100,23 +132,40 @@ func braveDoSearch(query string, offset, pageNum int, region string) (*Page, err
 		})
 	}
 
-	// Detect next page: Brave uses pagination links with offset parameter
-	hasNextLink := false
-	doc.Find("a[href]").EachWithBreak(func(i int, a *goquery.Selection) bool {
-		href, _ := a.Attr("href")
-		if strings.Contains(href, "offset=") {
-			hasNextLink = true
-			return false
-		}
-		return true
+	page.Answer = braveExtractAnswer(doc)
+	page.Correction = braveExtractCorrection(doc, query)
+	doc.Find("#related-queries a, .related-queries a").Each(func(i int, a *goquery.Selection) {
+		page.Related = appendUnique(page.Related, strings.TrimSpace(a.Text()))
 	})
-	if hasNextLink || len(page.Results) >= 10 {
-		page.HasMore = true
-	}
+
+	// Without pagination links, guess from a full page
+	hasNext, paginated := braveHasNext(doc, offset)
+	page.HasMore = hasNext || !paginated && len(page.Results) >= 10
 
 	return page, nil
 }
 
+// braveHasNext reports whether the page links to a later offset than the
+// current one. paginated is false when it has no offset links at all; the
+// link back to the previous page also carries an offset.
+func braveHasNext(doc *goquery.Document, offset int) (hasNext, paginated bool) {
+	doc.Find("a[href*='offset=']").EachWithBreak(func(i int, a *goquery.Selection) bool {
+		href, _ := a.Attr("href")
+		u, err := url.Parse(href)
+		if err != nil {
+			return true
+		}
+		n, err := strconv.Atoi(u.Query().Get("offset"))
+		if err != nil {
+			return true
+		}
+		paginated = true
+		hasNext = n > offset
+		return !hasNext
+	})
+	return hasNext, paginated
+}
+
 func braveExtractResult(s *goquery.Selection, page *Page) {
 	// Title: div.search-snippet-title or a.title text
 	var title string
@@ -166,31 +215,175 @@ func braveExtractResult(s *goquery.Selection, page *Page) {
 		Title:   title,
 		URL:     href,
 		Snippet: snippet,
+		Kind:    braveKind(s),
 	})
 }
 
-// braveRegion maps a short region code to Brave's country parameter.
+// braveExtractAnswer parses the infobox sidebar, falling back to the first
+// FAQ entry when no infobox is present.
+func braveExtractAnswer(doc *goquery.Document) *Answer {
+	if box := doc.Find("#infobox, .infobox").First(); box.Length() > 0 {
+		a := &Answer{
+			Title:   braveFirstText(box, ".infobox-title", "h1", "h2"),
+			Summary: braveFirstText(box, ".infobox-description", ".description", "p"),
+		}
+		box.Find("a[href]").EachWithBreak(func(i int, s *goquery.Selection) bool {
+			h, _ := s.Attr("href")
+			if strings.HasPrefix(h, "http") && !strings.Contains(h, "brave.com") {
+				a.URL = h
+				return false
+			}
+			return true
+		})
+		// Attribute rows are rendered either as a table or a definition list
+		box.Find("tr").Each(func(i int, s *goquery.Selection) {
+			name := strings.TrimSpace(s.Find("th, td").First().Text())
+			value := strings.TrimSpace(s.Find("td").Last().Text())
+			if name != "" && value != "" && name != value {
+				a.Facts = append(a.Facts, Field{Name: name, Value: value})
+			}
+		})
+		box.Find("dt").Each(func(i int, s *goquery.Selection) {
+			name := strings.TrimSpace(s.Text())
+			value := strings.TrimSpace(s.NextFiltered("dd").Text())
+			if name != "" && value != "" {
+				a.Facts = append(a.Facts, Field{Name: name, Value: value})
+			}
+		})
+		if a.Title != "" || a.Summary != "" {
+			return a
+		}
+	}
+
+	faq := doc.Find("#faq .faq-item, #faq details").First()
+	if faq.Length() > 0 {
+		a := &Answer{
+			Title:   braveFirstText(faq, "summary", ".faq-question"),
+			Summary: braveFirstText(faq, ".faq-answer", ".answer", "p"),
+		}
+		if h, ok := faq.Find("a[href^='http']").First().Attr("href"); ok {
+			a.URL = h
+		}
+		if a.Title != "" && a.Summary != "" {
+			return a
+		}
+	}
+	return nil
+}
+
+// braveExtractCorrection parses the "Showing results for" / "Did you mean"
+// banner.
+func braveExtractCorrection(doc *goquery.Document, query string) *Correction {
+	box := doc.Find("#altered-query, .altered-query").First()
+	if box.Length() == 0 {
+		return nil
+	}
+	corrected := strings.TrimSpace(box.Find("a").First().Text())
+	if corrected == "" || corrected == query {
+		return nil
+	}
+	text := strings.ToLower(box.Text())
+	return &Correction{
+		Query:    corrected,
+		Original: query,
+		Applied:  strings.Contains(text, "showing results for") || strings.Contains(text, "search instead"),
+	}
+}
+
+func braveFirstText(s *goquery.Selection, selectors ...string) string {
+	for _, sel := range selectors {
+		if t := strings.TrimSpace(s.Find(sel).First().Text()); t != "" {
+			return t
+		}
+	}
+	return ""
+}
+
+// braveKind reports whether a snippet is a sponsored placement.
+func braveKind(s *goquery.Selection) Kind {
+	if t, _ := s.Attr("data-type"); t == "ad" {
+		return KindAd
+	}
+	if s.HasClass("ad") || s.HasClass("sponsored") || s.Find(".ad-badge, .sponsored-label").Length() > 0 {
+		return KindAd
+	}
+	return KindOrganic
+}
+
+// braveRegionMap maps region codes to Brave's country parameter.
 var braveRegionMap = map[string]string{
-	"jp": "jp",
-	"us": "us",
-	"uk": "gb",
-	"de": "de",
-	"fr": "fr",
-	"es": "es",
-	"it": "it",
+	"ar": "ar",
+	"at": "at",
+	"au": "au",
+	"be": "be",
 	"br": "br",
 	"ca": "ca",
-	"au": "au",
+	"ch": "ch",
+	"cl": "cl",
+	"cn": "cn",
+	"de": "de",
+	"dk": "dk",
+	"es": "es",
+	"fi": "fi",
+	"fr": "fr",
+	"gb": "gb",
+	"hk": "hk",
+	"id": "id",
 	"in": "in",
+	"it": "it",
+	"jp": "jp",
 	"kr": "kr",
-	"cn": "cn",
-	"tw": "tw",
+	"mx": "mx",
+	"nl": "nl",
+	"no": "no",
+	"nz": "nz",
+	"pl": "pl",
+	"pt": "pt",
 	"ru": "ru",
+	"se": "se",
+	"tr": "tr",
+	"tw": "tw",
+	"us": "us",
+	"za": "za",
 }
 
+// braveRegion returns the country value for region, or "" when Brave has
+// none.
 func braveRegion(region string) string {
-	if r, ok := braveRegionMap[region]; ok {
-		return r
+	return braveRegionMap[region]
+}
+
+// braveLanguage returns the search_lang value for a BCP 47 tag. Brave uses
+// language subtags, except for a few languages it splits by script or
+// region, and "jp" for Japanese.
+func braveLanguage(tag string) string {
+	lower := strings.ToLower(tag)
+	switch lang := primaryLanguage(lower); lang {
+	case "ja":
+		return "jp"
+	case "zh":
+		if strings.Contains(lower, "-hant") || strings.HasSuffix(lower, "-tw") || strings.HasSuffix(lower, "-hk") {
+			return "zh-hant"
+		}
+		return "zh-hans"
+	case "pt":
+		if strings.HasSuffix(lower, "-pt") {
+			return "pt-pt"
+		}
+		return "pt-br"
+	case "en":
+		if strings.HasSuffix(lower, "-gb") {
+			return "en-gb"
+		}
+		return "en"
+	default:
+		return lang
 	}
-	return region
+}
+
+// headers returns the request headers with the configured language.
+func (b *Brave) headers() http.Header {
+	h := braveHeaders.Clone()
+	h.Set("Accept-Language", acceptLanguage(b.Language))
+	return h
 }
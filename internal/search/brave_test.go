@@ -0,0 +1,63 @@
+package search
+
+import (
+	"testing"
+)
+
+func TestBraveKind(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want Kind
+	}{
+		{"organic", `<div class="snippet" data-type="web"><a href="https://go.dev">Go</a></div>`, KindOrganic},
+		{"data-type ad", `<div class="snippet" data-type="ad"><a href="https://ads.example.com">Ad</a></div>`, KindAd},
+		{"ad class", `<div class="snippet ad" data-type="web"></div>`, KindAd},
+		{"sponsored class", `<div class="snippet sponsored" data-type="web"></div>`, KindAd},
+		{"ad badge", `<div class="snippet" data-type="web"><span class="ad-badge">Ad</span></div>`, KindAd},
+		{"sponsored label", `<div class="snippet" data-type="web"><span class="sponsored-label">Sponsored</span></div>`, KindAd},
+		{"ad in text only", `<div class="snippet" data-type="web"><p>ad free hosting</p></div>`, KindOrganic},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := docFromString(t, tt.html).Find("div.snippet")
+			if got := braveKind(s); got != tt.want {
+				t.Errorf("braveKind = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBraveLanguage(t *testing.T) {
+	tests := map[string]string{
+		"":        "",
+		"de":      "de",
+		"ja-JP":   "jp",
+		"zh-TW":   "zh-hant",
+		"zh-Hant": "zh-hant",
+		"zh-CN":   "zh-hans",
+		"pt-PT":   "pt-pt",
+		"pt":      "pt-br",
+		"en-GB":   "en-gb",
+		"en-US":   "en",
+	}
+	for tag, want := range tests {
+		if got := braveLanguage(tag); got != want {
+			t.Errorf("braveLanguage(%q) = %q, want %q", tag, got, want)
+		}
+	}
+}
+
+func TestBraveHasNext(t *testing.T) {
+	// The fixture is page 2 (offset 1), linking back to offset 0 and on to 2
+	doc := fixtureDoc(t, "brave.html")
+	if next, paged := braveHasNext(doc, 1); !next || !paged {
+		t.Errorf("page 2: next %v, paginated %v", next, paged)
+	}
+	if next, paged := braveHasNext(doc, 2); next || !paged {
+		t.Errorf("last page: next %v, paginated %v", next, paged)
+	}
+	if next, paged := braveHasNext(docFromString(t, `<a href="https://go.dev/">Go</a>`), 0); next || paged {
+		t.Errorf("no pagination: next %v, paginated %v", next, paged)
+	}
+}
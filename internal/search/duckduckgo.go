@@ -1,6 +1,7 @@
 package search
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/cookiejar"
@@ -18,7 +19,12 @@ func init() {
 }
 
 type DuckDuckGo struct {
-	Region string // e.g. "jp", "us", "de"
+	Region     string // e.g. "jp", "us", "de"
+	Language   string // BCP 47 tag sent as Accept-Language; also picks the language of regions that have several
+	SafeSearch SafeSearch
+	// SuggestEndpoint overrides the completion endpoint, e.g. to point at a
+	// local stub server.
+	SuggestEndpoint string
 }
 
 const ddgEndpoint = "https://html.duckduckgo.com/html/"
@@ -35,10 +41,11 @@ func (d *DuckDuckGo) Name() string { return "duckduckgo" }
 
 func (d *DuckDuckGo) Search(query string) (*Page, error) {
 	form := url.Values{"q": {query}}
-	if kl := ddgRegion(d.Region); kl != "" {
+	if kl := ddgRegion(d.Region, d.Language); kl != "" {
 		form.Set("kl", kl)
 	}
-	return ddgDoSearch(form, 1)
+	d.setSafeSearch(form)
+	return d.doSearch(form, 1)
 }
 
 func (d *DuckDuckGo) NextPage(prev *Page, query string) (*Page, error) {
@@ -50,35 +57,38 @@ func (d *DuckDuckGo) NextPage(prev *Page, query string) (*Page, error) {
 		form[k] = v
 	}
 	form.Set("q", query)
-	return ddgDoSearch(form, prev.PageNum+1)
+	d.setSafeSearch(form)
+	return d.doSearch(form, prev.PageNum+1)
 }
 
-func (d *DuckDuckGo) PrevPage(query string, pageNum int) (*Page, error) {
-	if pageNum <= 1 {
-		return d.Search(query)
-	}
-	page, err := d.Search(query)
-	if err != nil {
-		return nil, err
-	}
-	for i := 1; i < pageNum; i++ {
-		if !page.HasMore {
-			return page, nil
-		}
-		page, err = d.NextPage(page, query)
-		if err != nil {
-			return nil, err
-		}
+// setSafeSearch sets DuckDuckGo's kp parameter.
+func (d *DuckDuckGo) setSafeSearch(form url.Values) {
+	switch d.SafeSearch {
+	case SafeStrict:
+		form.Set("kp", "1")
+	case SafeModerate:
+		form.Set("kp", "-1")
+	case SafeOff:
+		form.Set("kp", "-2")
 	}
-	return page, nil
 }
 
-func ddgDoSearch(form url.Values, pageNum int) (*Page, error) {
+func (d *DuckDuckGo) PrevPage(query string, pageNum int) (*Page, error) {
+	return d.PrevPageContext(context.Background(), query, pageNum)
+}
+
+// PrevPageContext replays the pages before pageNum: DuckDuckGo's next-page
+// form is the only way to reach a page.
+func (d *DuckDuckGo) PrevPageContext(ctx context.Context, query string, pageNum int) (*Page, error) {
+	return replay(ctx, d, query, pageNum)
+}
+
+func (d *DuckDuckGo) doSearch(form url.Values, pageNum int) (*Page, error) {
 	req, err := http.NewRequest("POST", ddgEndpoint, strings.NewReader(form.Encode()))
 	if err != nil {
 		return nil, fmt.Errorf("creating request: %w", err)
 	}
-	req.Header = ddgHeaders.Clone()
+	req.Header = d.headers()
 
 	resp, err := ddgClient.Do(req)
 	if err != nil {
@@ -97,7 +107,11 @@ func ddgDoSearch(form url.Values, pageNum int) (*Page, error) {
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("search returned status %d", resp.StatusCode)
 	}
+	return ddgParse(doc, form.Get("q"), pageNum), nil
+}
 
+// ddgParse extracts a results page from DuckDuckGo's HTML.
+func ddgParse(doc *goquery.Document, query string, pageNum int) *Page {
 	page := &Page{PageNum: pageNum}
 
 	doc.Find(".result.results_links").Each(func(i int, s *goquery.Selection) {
@@ -109,15 +123,27 @@ func ddgDoSearch(form url.Values, pageNum int) (*Page, error) {
 
 		snippet := strings.TrimSpace(s.Find(".result__snippet").Text())
 
+		kind := KindOrganic
+		if s.HasClass("result--ad") {
+			kind = KindAd
+		}
+
 		if title != "" && href != "" {
 			page.Results = append(page.Results, Result{
 				Title:   title,
 				URL:     href,
 				Snippet: snippet,
+				Kind:    kind,
 			})
 		}
 	})
 
+	page.Answer = ddgExtractAnswer(doc)
+	page.Correction = ddgExtractCorrection(doc, query)
+	doc.Find("#related_searches a, .related-searches a").Each(func(i int, a *goquery.Selection) {
+		page.Related = appendUnique(page.Related, strings.TrimSpace(a.Text()))
+	})
+
 	navForm := doc.Find(".nav-link form")
 	if navForm.Length() > 0 {
 		page.HasMore = true
@@ -130,48 +156,146 @@ func ddgDoSearch(form url.Values, pageNum int) (*Page, error) {
 			}
 		})
 	}
+	return page
+}
+
+// ddgExtractAnswer parses the zero-click info box shown above the results.
+func ddgExtractAnswer(doc *goquery.Document) *Answer {
+	zci := doc.Find(".zci").First()
+	if zci.Length() == 0 {
+		return nil
+	}
+	heading := zci.Find(".zci__heading a").First()
+	title := strings.TrimSpace(heading.Text())
+	if title == "" {
+		title = strings.TrimSpace(zci.Find(".zci__heading").First().Text())
+	}
+	href, _ := heading.Attr("href")
+
+	body := zci.Find(".zci__result").First().Clone()
+	// Drop the trailing "More at Wikipedia" link but keep its target as the source
+	more := body.Find("a").Last()
+	if src, ok := more.Attr("href"); ok && strings.HasPrefix(strings.TrimSpace(more.Text()), "More at") {
+		if href == "" {
+			href = src
+		}
+		more.Remove()
+	}
+	summary := strings.TrimSpace(body.Text())
 
-	return page, nil
+	if title == "" && summary == "" {
+		return nil
+	}
+	return &Answer{
+		Title:   title,
+		Summary: summary,
+		URL:     ddgCleanURL(href),
+	}
 }
 
-func ddgCleanURL(rawURL string) string {
-	if strings.HasPrefix(rawURL, "//duckduckgo.com/l/?uddg=") {
-		parsed, err := url.Parse("https:" + rawURL)
-		if err == nil {
-			if uddg := parsed.Query().Get("uddg"); uddg != "" {
-				return uddg
+// ddgExtractCorrection parses the "Did you mean" / "Including results for"
+// message. When the correction was applied, the "Search only for" link gives
+// the query that bypasses it; DuckDuckGo has no parameter to turn correction
+// off, so without that link the original spelling cannot be searched.
+func ddgExtractCorrection(doc *goquery.Document, query string) *Correction {
+	box := doc.Find("#did_you_mean").First()
+	if box.Length() == 0 {
+		return nil
+	}
+	links := box.Find("a")
+	corrected := ddgLinkQuery(links.First())
+	if corrected == "" || corrected == query {
+		return nil
+	}
+	c := &Correction{Query: corrected, Original: query}
+	text := strings.ToLower(box.Text())
+	if strings.Contains(text, "including results for") || strings.Contains(text, "showing results for") {
+		c.Applied = true
+		c.Original = ""
+		if links.Length() > 1 {
+			c.Original = ddgLinkQuery(links.Eq(1))
+		}
+	}
+	return c
+}
+
+// ddgLinkQuery returns the q parameter of a DDG search link, or its text.
+func ddgLinkQuery(a *goquery.Selection) string {
+	if href, ok := a.Attr("href"); ok {
+		if u, err := url.Parse(href); err == nil {
+			if q := u.Query().Get("q"); q != "" {
+				return q
 			}
 		}
 	}
-	return rawURL
+	return strings.TrimSpace(a.Text())
+}
+
+func ddgCleanURL(rawURL string) string {
+	return Unwrap(rawURL)
 }
 
-// ddgRegion maps a short region code to DuckDuckGo's kl parameter.
+// ddgRegionMap maps region codes to DuckDuckGo's kl parameter.
 var ddgRegionMap = map[string]string{
-	"jp": "jp-jp",
-	"us": "us-en",
-	"uk": "uk-en",
-	"de": "de-de",
-	"fr": "fr-fr",
-	"es": "es-es",
-	"it": "it-it",
+	"ar": "ar-es",
+	"at": "at-de",
+	"au": "au-en",
+	"be": "be-nl",
 	"br": "br-pt",
 	"ca": "ca-en",
-	"au": "au-en",
+	"ch": "ch-de",
+	"cl": "cl-es",
+	"cn": "cn-zh",
+	"de": "de-de",
+	"dk": "dk-da",
+	"es": "es-es",
+	"fi": "fi-fi",
+	"fr": "fr-fr",
+	"gb": "uk-en",
+	"hk": "hk-tzh",
+	"id": "id-en",
+	"ie": "ie-en",
 	"in": "in-en",
+	"it": "it-it",
+	"jp": "jp-jp",
 	"kr": "kr-kr",
-	"cn": "cn-zh",
-	"tw": "tw-tzh",
+	"mx": "mx-es",
+	"nl": "nl-nl",
+	"no": "no-no",
+	"nz": "nz-en",
+	"pl": "pl-pl",
+	"pt": "pt-pt",
 	"ru": "ru-ru",
+	"se": "se-sv",
+	"tr": "tr-tr",
+	"tw": "tw-tzh",
+	"us": "us-en",
+	"za": "za-en",
 }
 
-func ddgRegion(region string) string {
-	if region == "" {
-		return ""
-	}
-	if kl, ok := ddgRegionMap[region]; ok {
+// headers returns the request headers with the configured language.
+func (d *DuckDuckGo) headers() http.Header {
+	h := ddgHeaders.Clone()
+	h.Set("Accept-Language", acceptLanguage(d.Language))
+	return h
+}
+
+// ddgLanguageRegions holds the kl values of the regions DuckDuckGo offers
+// in several languages, by language subtag.
+var ddgLanguageRegions = map[string]map[string]string{
+	"be": {"fr": "be-fr", "nl": "be-nl"},
+	"ca": {"en": "ca-en", "fr": "ca-fr"},
+	"ch": {"de": "ch-de", "fr": "ch-fr", "it": "ch-it"},
+	"es": {"ca": "es-ca", "es": "es-es"},
+	"us": {"en": "us-en", "es": "us-es"},
+}
+
+// ddgRegion returns the kl value for region, in language where DuckDuckGo
+// has that variant, or "" when DuckDuckGo has none. kl is also DuckDuckGo's
+// only language parameter.
+func ddgRegion(region, language string) string {
+	if kl, ok := ddgLanguageRegions[region][primaryLanguage(language)]; ok {
 		return kl
 	}
-	// Fallback: try "xx-xx" pattern
-	return region + "-" + region
+	return ddgRegionMap[region]
 }
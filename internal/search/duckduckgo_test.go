@@ -0,0 +1,102 @@
+package search
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDDGParse(t *testing.T) {
+	page := ddgParse(fixtureDoc(t, "duckduckgo.html"), "golang gnerics", 1)
+
+	if len(page.Results) != 3 {
+		t.Fatalf("got %d results, want 3", len(page.Results))
+	}
+	ad := page.Results[0]
+	if !ad.IsAd() || ad.Title != "Learn Go Fast - Online Course" {
+		t.Errorf("first result = %+v, want the ad", ad)
+	}
+	for _, r := range page.Results[1:] {
+		if r.IsAd() {
+			t.Errorf("organic result %q detected as an ad", r.Title)
+		}
+	}
+	if got, want := page.Results[1].URL, "https://go.dev/doc/tutorial/generics"; got != want {
+		t.Errorf("redirect URL unwrapped to %q, want %q", got, want)
+	}
+	if got, want := page.Results[1].Snippet, "This tutorial introduces the basics of generics in Go."; got != want {
+		t.Errorf("snippet = %q, want %q", got, want)
+	}
+
+	if a := page.Answer; a == nil || a.Title != "Go (programming language)" || a.URL != "https://en.wikipedia.org/wiki/Go_(programming_language)" {
+		t.Errorf("answer = %+v", a)
+	}
+	if c := page.Correction; c == nil || c.Query != "golang generics" || !c.Applied || c.Original != "+golang gnerics" {
+		t.Errorf("correction = %+v", c)
+	}
+	if want := []string{"golang generics tutorial", "golang generics constraints"}; !slices.Equal(page.Related, want) {
+		t.Errorf("related = %q, want %q", page.Related, want)
+	}
+
+	if !page.HasMore {
+		t.Fatal("HasMore = false, want true")
+	}
+	if page.NextParams.Get("s") != "10" || page.NextParams.Get("vqd") != "4-123" {
+		t.Errorf("next params = %v", page.NextParams)
+	}
+}
+
+func TestDDGRegion(t *testing.T) {
+	tests := []struct{ region, language, want string }{
+		{"ca", "", "ca-en"},
+		{"ca", "fr-CA", "ca-fr"},
+		{"ch", "it", "ch-it"},
+		{"jp", "en", "jp-jp"}, // no English variant
+		{"", "fr", ""},
+	}
+	for _, tt := range tests {
+		if got := ddgRegion(tt.region, tt.language); got != tt.want {
+			t.Errorf("ddgRegion(%q, %q) = %q, want %q", tt.region, tt.language, got, tt.want)
+		}
+	}
+}
+
+func TestDDGParseEmpty(t *testing.T) {
+	page := ddgParse(docFromString(t, "<html><body><div class=\"no-results\">No results.</div></body></html>"), "x", 1)
+	if len(page.Results) != 0 || page.HasMore || page.Answer != nil || page.Correction != nil {
+		t.Errorf("page = %+v, want empty", page)
+	}
+}
+
+func TestDDGExtractCorrection(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want *Correction
+	}{
+		{
+			"suggested",
+			`<div id="did_you_mean">Did you mean <a href="/html/?q=golang">golang</a>?</div>`,
+			&Correction{Query: "golang", Original: "golnag"},
+		},
+		{
+			"applied with bypass link",
+			`<div id="did_you_mean">Including results for <a href="/html/?q=golang">golang</a>. Search only for <a href="/html/?q=%2Bgolnag">golnag</a></div>`,
+			&Correction{Query: "golang", Original: "+golnag", Applied: true},
+		},
+		{
+			// Without the link there is no way to search the original spelling
+			"applied without bypass link",
+			`<div id="did_you_mean">Including results for <a href="/html/?q=golang">golang</a>.</div>`,
+			&Correction{Query: "golang", Applied: true},
+		},
+		{"none", `<div></div>`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ddgExtractCorrection(docFromString(t, tt.html), "golnag")
+			if (got == nil) != (tt.want == nil) || got != nil && *got != *tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
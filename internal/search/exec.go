@@ -0,0 +1,198 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ExecProtocolVersion is the version of the plugin stdin/stdout protocol.
+const ExecProtocolVersion = 1
+
+// DefaultExecTimeout bounds a plugin run when no timeout is configured.
+const DefaultExecTimeout = 10 * time.Second
+
+// maxStderr caps how much plugin stderr is kept for error messages.
+const maxStderr = 4096
+
+// ExecBackend runs an external executable as a search engine. The request
+// is written to its stdin as JSON and a page is read back from stdout.
+type ExecBackend struct {
+	EngineName string
+	Command    string
+	Args       []string
+	Timeout    time.Duration
+	Region     string
+	Language   string
+	Options    map[string]any // passed through to the plugin verbatim
+
+	// cursors holds the cursor each page of cursorQuery was first fetched
+	// with. Only the latest query is kept, so the map stays small.
+	mu          sync.Mutex
+	cursorQuery string
+	cursors     map[int]string
+}
+
+// ExecRequest is written to the plugin's stdin.
+type ExecRequest struct {
+	Version  int            `json:"version"`
+	Query    string         `json:"query"`
+	Page     int            `json:"page"`
+	Cursor   string         `json:"cursor,omitempty"`
+	Region   string         `json:"region,omitempty"`
+	Language string         `json:"language,omitempty"` // BCP 47 tag
+	Options  map[string]any `json:"options,omitempty"`
+}
+
+// ExecResponse is read from the plugin's stdout. It carries the fields of
+// Page, so a plugin may also return an answer, a correction and related
+// searches.
+type ExecResponse struct {
+	Version int `json:"version"`
+	Page
+	Cursor string `json:"cursor,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+func (e *ExecBackend) Name() string { return e.EngineName }
+
+func (e *ExecBackend) Search(query string) (*Page, error) {
+	return e.run(context.Background(), query, 1, "")
+}
+
+func (e *ExecBackend) NextPage(prev *Page, query string) (*Page, error) {
+	if !prev.HasMore {
+		return nil, fmt.Errorf("no more pages")
+	}
+	return e.run(context.Background(), query, prev.PageNum+1, prev.NextParams.Get("cursor"))
+}
+
+// PrevPage asks for the page again with the cursor it was first fetched
+// with. When that is unknown it follows cursors from the first page, as a
+// plugin that only understands cursors would otherwise return the first
+// page again.
+func (e *ExecBackend) PrevPage(query string, pageNum int) (*Page, error) {
+	return e.PrevPageContext(context.Background(), query, pageNum)
+}
+
+func (e *ExecBackend) PrevPageContext(ctx context.Context, query string, pageNum int) (*Page, error) {
+	if pageNum <= 1 {
+		return e.run(ctx, query, 1, "")
+	}
+	e.mu.Lock()
+	cursor, ok := e.cursors[pageNum]
+	ok = ok && e.cursorQuery == query
+	e.mu.Unlock()
+	if ok {
+		return e.run(ctx, query, pageNum, cursor)
+	}
+
+	page, err := e.run(ctx, query, 1, "")
+	if err != nil {
+		return nil, err
+	}
+	for page.PageNum < pageNum && page.HasMore {
+		if page.NextParams.Get("cursor") == "" {
+			// Paged by number only
+			return e.run(ctx, query, pageNum, "")
+		}
+		if page, err = e.run(ctx, query, page.PageNum+1, page.NextParams.Get("cursor")); err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+func (e *ExecBackend) run(ctx context.Context, query string, pageNum int, cursor string) (*Page, error) {
+	req, err := json.Marshal(ExecRequest{
+		Version:  ExecProtocolVersion,
+		Query:    query,
+		Page:     pageNum,
+		Cursor:   cursor,
+		Region:   e.Region,
+		Language: e.Language,
+		Options:  e.Options,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("encoding request: %w", err)
+	}
+
+	timeout := e.Timeout
+	if timeout <= 0 {
+		timeout = DefaultExecTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, e.Command, e.Args...)
+	cmd.Stdin = bytes.NewReader(req)
+	var stdout bytes.Buffer
+	stderr := &limitedBuffer{max: maxStderr}
+	cmd.Stdout = &stdout
+	cmd.Stderr = stderr
+	cmd.WaitDelay = time.Second
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%s: timed out after %s%s", e.EngineName, timeout, stderr.suffix())
+		}
+		return nil, fmt.Errorf("%s: %w%s", e.EngineName, err, stderr.suffix())
+	}
+
+	var resp ExecResponse
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return nil, fmt.Errorf("%s: parsing output: %w%s", e.EngineName, err, stderr.suffix())
+	}
+	if resp.Version != ExecProtocolVersion {
+		return nil, fmt.Errorf("%s: unsupported protocol version %d (want %d)", e.EngineName, resp.Version, ExecProtocolVersion)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("%s: %s", e.EngineName, resp.Error)
+	}
+
+	page := &resp.Page
+	page.PageNum = pageNum
+	page.NextParams = nil
+	page.Literal = false
+	page.Results = slices.DeleteFunc(page.Results, func(r Result) bool { return r.Title == "" || r.URL == "" })
+	e.mu.Lock()
+	if e.cursors == nil || e.cursorQuery != query {
+		e.cursorQuery, e.cursors = query, make(map[int]string)
+	}
+	if resp.Cursor != "" {
+		page.NextParams = url.Values{"cursor": {resp.Cursor}}
+		e.cursors[pageNum+1] = resp.Cursor
+	}
+	e.mu.Unlock()
+	return page, nil
+}
+
+// limitedBuffer keeps the first max bytes written to it.
+type limitedBuffer struct {
+	buf bytes.Buffer
+	max int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.buf.Len(); room > 0 {
+		b.buf.Write(p[:min(len(p), room)])
+	}
+	return len(p), nil
+}
+
+// suffix formats captured stderr for appending to an error message.
+func (b *limitedBuffer) suffix() string {
+	s := strings.TrimSpace(b.buf.String())
+	if s == "" {
+		return ""
+	}
+	return ": " + strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,114 @@
+package search
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestMain lets the test binary act as a plugin: with KSK_TEST_PLUGIN set it
+// answers one request instead of running the tests.
+func TestMain(m *testing.M) {
+	if os.Getenv("KSK_TEST_PLUGIN") != "" {
+		cursorPlugin()
+		return
+	}
+	os.Exit(m.Run())
+}
+
+// cursorPlugin pages by cursor only and ignores the page number, serving
+// three pages.
+func cursorPlugin() {
+	var req ExecRequest
+	if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	n := 1
+	if c, ok := strings.CutPrefix(req.Cursor, "after-"); ok {
+		fmt.Sscan(c, &n)
+		n++
+	}
+	resp := map[string]any{
+		"version": ExecProtocolVersion,
+		"results": []map[string]any{
+			{"title": fmt.Sprintf("%s %d", req.Query, n), "url": fmt.Sprintf("https://example.com/%d", n)},
+			{"title": "Sponsored", "url": "https://ads.example.com", "kind": KindAd},
+			{"title": "no URL"},
+		},
+		"related":  []string{req.Query + " tips"},
+		"has_more": n < 3,
+	}
+	if n < 3 {
+		resp["cursor"] = fmt.Sprintf("after-%d", n)
+	}
+	json.NewEncoder(os.Stdout).Encode(resp)
+}
+
+func testPlugin(t *testing.T) *ExecBackend {
+	t.Setenv("KSK_TEST_PLUGIN", "1")
+	return &ExecBackend{EngineName: "test", Command: os.Args[0]}
+}
+
+func TestExecBackend(t *testing.T) {
+	e := testPlugin(t)
+	page, err := e.Search("deploy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Results) != 2 || page.Results[0].Title != "deploy 1" || !page.Results[1].IsAd() {
+		t.Errorf("results = %+v", page.Results)
+	}
+	if len(page.Related) != 1 || page.Related[0] != "deploy tips" {
+		t.Errorf("related = %q", page.Related)
+	}
+	if !page.HasMore || page.NextParams.Get("cursor") != "after-1" {
+		t.Errorf("has more %v, next %v", page.HasMore, page.NextParams)
+	}
+
+	page, err = e.NextPage(page, "deploy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.PageNum != 2 || page.Results[0].Title != "deploy 2" {
+		t.Errorf("page %d starts with %q", page.PageNum, page.Results[0].Title)
+	}
+}
+
+func TestExecBackendPrevPage(t *testing.T) {
+	// Unknown cursor: walk from the first page
+	e := testPlugin(t)
+	page, err := e.PrevPage("deploy", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.PageNum != 3 || page.Results[0].Title != "deploy 3" || page.HasMore {
+		t.Errorf("page %d starts with %q, has more %v", page.PageNum, page.Results[0].Title, page.HasMore)
+	}
+
+	// Known cursor: fetched directly
+	page, err = e.PrevPage("deploy", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.PageNum != 2 || page.Results[0].Title != "deploy 2" {
+		t.Errorf("page %d starts with %q", page.PageNum, page.Results[0].Title)
+	}
+
+	// A new query drops the cursors of the last one
+	if _, err := e.Search("build"); err != nil {
+		t.Fatal(err)
+	}
+	if e.cursorQuery != "build" || len(e.cursors) != 1 {
+		t.Errorf("cursors of %q: %v", e.cursorQuery, e.cursors)
+	}
+	page, err = e.PrevPage("deploy", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.PageNum != 2 || page.Results[0].Title != "deploy 2" {
+		t.Errorf("page %d starts with %q", page.PageNum, page.Results[0].Title)
+	}
+}
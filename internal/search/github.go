@@ -0,0 +1,177 @@
+package search
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+func init() {
+	for _, kind := range []string{"repositories", "issues", "code"} {
+		name := "github"
+		aliases := []string{"gh"}
+		if kind != "repositories" {
+			name += "-" + kind
+			aliases = []string{"gh-" + kind}
+		}
+		Register(name, aliases, func(o Options) (Backend, error) {
+			return &GitHub{Kind: kind, Token: os.Getenv("GITHUB_TOKEN")}, nil
+		})
+	}
+}
+
+// GitHub searches repositories, issues/PRs or code through the REST search
+// API. Code search requires a token.
+type GitHub struct {
+	Kind  string // "repositories" (default), "issues" or "code"
+	Token string // optional; raises rate limits
+	// Endpoint overrides the search API base URL, which the kind is
+	// appended to.
+	Endpoint string
+}
+
+const (
+	githubEndpoint = "https://api.github.com/search/"
+	githubPageSize = 10
+	// The search API never returns more than 1000 results
+	githubMaxResults = 1000
+)
+
+func (g *GitHub) Name() string {
+	if g.Kind == "" || g.Kind == "repositories" {
+		return "github"
+	}
+	return "github-" + g.Kind
+}
+
+func (g *GitHub) Search(query string) (*Page, error) {
+	return g.fetch(query, 1)
+}
+
+func (g *GitHub) NextPage(prev *Page, query string) (*Page, error) {
+	if !prev.HasMore {
+		return nil, fmt.Errorf("no more pages")
+	}
+	return g.fetch(query, prev.PageNum+1)
+}
+
+func (g *GitHub) PrevPage(query string, pageNum int) (*Page, error) {
+	return g.fetch(query, max(1, pageNum))
+}
+
+func (g *GitHub) fetch(query string, pageNum int) (*Page, error) {
+	kind := g.Kind
+	if kind == "" {
+		kind = "repositories"
+	}
+	if kind == "code" && g.Token == "" {
+		return nil, fmt.Errorf("GitHub code search requires GITHUB_TOKEN")
+	}
+
+	params := url.Values{
+		"q":        {query},
+		"page":     {fmt.Sprint(pageNum)},
+		"per_page": {fmt.Sprint(githubPageSize)},
+	}
+	header := http.Header{
+		// text-match adds highlighted fragments, used as code snippets
+		"Accept":               {"application/vnd.github.text-match+json"},
+		"X-GitHub-Api-Version": {"2022-11-28"},
+	}
+	if g.Token != "" {
+		header.Set("Authorization", "Bearer "+g.Token)
+	}
+
+	var resp struct {
+		TotalCount int            `json:"total_count"`
+		Items      []githubResult `json:"items"`
+	}
+	if err := getJSON(cmp.Or(g.Endpoint, githubEndpoint)+kind, params, header, &resp); err != nil {
+		return nil, err
+	}
+
+	page := &Page{
+		PageNum: pageNum,
+		HasMore: pageNum*githubPageSize < min(resp.TotalCount, githubMaxResults),
+	}
+	for _, it := range resp.Items {
+		page.Results = append(page.Results, it.result(kind))
+	}
+	return page, nil
+}
+
+type githubResult struct {
+	HTMLURL string `json:"html_url"`
+
+	// repositories
+	FullName    string `json:"full_name"`
+	Description string `json:"description"`
+	Stars       int    `json:"stargazers_count"`
+	Language    string `json:"language"`
+	UpdatedAt   string `json:"updated_at"`
+
+	// issues
+	Title         string    `json:"title"`
+	Number        int       `json:"number"`
+	State         string    `json:"state"`
+	Comments      int       `json:"comments"`
+	Body          string    `json:"body"`
+	RepositoryURL string    `json:"repository_url"`
+	PullRequest   *struct{} `json:"pull_request"`
+
+	// code
+	Path       string `json:"path"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+	TextMatches []struct {
+		Fragment string `json:"fragment"`
+	} `json:"text_matches"`
+}
+
+func (it githubResult) result(kind string) Result {
+	switch kind {
+	case "issues":
+		repo := strings.TrimPrefix(it.RepositoryURL, "https://api.github.com/repos/")
+		typ := "Issue"
+		if it.PullRequest != nil {
+			typ = "PR"
+		}
+		return Result{
+			Title:   fmt.Sprintf("%s#%d %s", repo, it.Number, it.Title),
+			URL:     it.HTMLURL,
+			Snippet: strings.Join(strings.Fields(it.Body), " "),
+			Meta: []Field{
+				{Name: typ, Value: it.State},
+				{Name: "Comments", Value: fmt.Sprint(it.Comments)},
+				{Name: "Updated", Value: shortDate(it.UpdatedAt)},
+			},
+		}
+	case "code":
+		var snippet string
+		if len(it.TextMatches) > 0 {
+			snippet = strings.Join(strings.Fields(it.TextMatches[0].Fragment), " ")
+		}
+		return Result{
+			Title:   it.Repository.FullName + ": " + it.Path,
+			URL:     it.HTMLURL,
+			Snippet: snippet,
+			Meta:    []Field{{Name: "Repo", Value: it.Repository.FullName}},
+		}
+	}
+
+	meta := []Field{{Name: "Stars", Value: humanCount(it.Stars)}}
+	if it.Language != "" {
+		meta = append(meta, Field{Name: "Language", Value: it.Language})
+	}
+	meta = append(meta, Field{Name: "Updated", Value: shortDate(it.UpdatedAt)})
+	return Result{
+		Title:   it.FullName,
+		URL:     it.HTMLURL,
+		Snippet: it.Description,
+		Meta:    meta,
+	}
+}
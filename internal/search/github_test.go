@@ -0,0 +1,81 @@
+package search
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestGitHubRepositories(t *testing.T) {
+	srv, got := serveFixture(t, "github_repositories.json")
+	g := &GitHub{Endpoint: srv.URL + "/search/"}
+	page, err := g.fetch("golang", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if (*got).Path != "/search/repositories" {
+		t.Errorf("path = %q, want /search/repositories", (*got).Path)
+	}
+	if q := (*got).Query(); q.Get("q") != "golang" || q.Get("page") != "2" {
+		t.Errorf("query = %v", q)
+	}
+
+	want := []Result{
+		{
+			Title: "golang/go", URL: "https://github.com/golang/go", Snippet: "The Go programming language",
+			Meta: []Field{{"Stars", "128.5k"}, {"Language", "Go"}, {"Updated", "2025-03-14"}},
+		},
+		{
+			Title: "golang/proposal", URL: "https://github.com/golang/proposal", Snippet: "Go Project Design Documents",
+			Meta: []Field{{"Stars", "3.5k"}, {"Updated", "2025-02-01"}},
+		},
+	}
+	if !slices.EqualFunc(page.Results, want, resultEqual) {
+		t.Errorf("results = %+v\nwant %+v", page.Results, want)
+	}
+	// 25 results in pages of 10: page 2 is followed by page 3
+	if page.PageNum != 2 || !page.HasMore {
+		t.Errorf("page %d, has more %v", page.PageNum, page.HasMore)
+	}
+	page, _ = g.fetch("golang", 3)
+	if page.HasMore {
+		t.Error("last page has more")
+	}
+}
+
+func TestGitHubIssues(t *testing.T) {
+	srv, got := serveFixture(t, "github_issues.json")
+	g := &GitHub{Kind: "issues", Endpoint: srv.URL + "/search/"}
+	page, err := g.Search("type parameters")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if (*got).Path != "/search/issues" {
+		t.Errorf("path = %q, want /search/issues", (*got).Path)
+	}
+
+	want := []Result{
+		{
+			Title:   "golang/go#43651 spec: add generic programming using type parameters",
+			URL:     "https://github.com/golang/go/issues/43651",
+			Snippet: "We propose adding support for type parameters to types and functions.",
+			Meta:    []Field{{"Issue", "closed"}, {"Comments", "1201"}, {"Updated", "2024-06-20"}},
+		},
+		{
+			Title: "golang/go#60000 cmd/go: fix typo", URL: "https://github.com/golang/go/pull/60000",
+			Meta: []Field{{"PR", "open"}, {"Comments", "3"}, {"Updated", "2024-07-01"}},
+		},
+	}
+	if !slices.EqualFunc(page.Results, want, resultEqual) {
+		t.Errorf("results = %+v\nwant %+v", page.Results, want)
+	}
+	if page.HasMore {
+		t.Error("single page has more")
+	}
+}
+
+func TestGitHubCodeNeedsToken(t *testing.T) {
+	g := &GitHub{Kind: "code", Endpoint: "http://127.0.0.1:0/"}
+	if _, err := g.Search("x"); err == nil {
+		t.Error("code search without a token succeeded")
+	}
+}
@@ -0,0 +1,82 @@
+package search
+
+import (
+	"cmp"
+	"fmt"
+	"net/url"
+	"os"
+)
+
+func init() {
+	Register("marginalia", []string{"mg"}, func(o Options) (Backend, error) {
+		return &Marginalia{Key: os.Getenv("MARGINALIA_API_KEY")}, nil
+	})
+}
+
+// Marginalia searches the Marginalia Search index of small, non-commercial
+// sites through its public API. The index is largely English and has no
+// region parameter.
+type Marginalia struct {
+	Key      string // API key; the shared "public" key is used when empty
+	Endpoint string // overrides the API base URL
+}
+
+const (
+	marginaliaEndpoint = "https://api.marginalia.nu/"
+	marginaliaPageSize = 10
+	// The API has no offset: pages are taken from one growing result list
+	marginaliaMaxResults = 100
+)
+
+func (m *Marginalia) Name() string { return "marginalia" }
+
+func (m *Marginalia) Search(query string) (*Page, error) {
+	return m.fetch(query, 1)
+}
+
+func (m *Marginalia) NextPage(prev *Page, query string) (*Page, error) {
+	if !prev.HasMore {
+		return nil, fmt.Errorf("no more pages")
+	}
+	return m.fetch(query, prev.PageNum+1)
+}
+
+func (m *Marginalia) PrevPage(query string, pageNum int) (*Page, error) {
+	return m.fetch(query, max(1, pageNum))
+}
+
+func (m *Marginalia) fetch(query string, pageNum int) (*Page, error) {
+	count := min(pageNum*marginaliaPageSize, marginaliaMaxResults)
+	endpoint := cmp.Or(m.Endpoint, marginaliaEndpoint) + url.PathEscape(cmp.Or(m.Key, "public")) + "/search/" + url.PathEscape(query)
+
+	var resp struct {
+		Results []struct {
+			URL         string  `json:"url"`
+			Title       string  `json:"title"`
+			Description string  `json:"description"`
+			Quality     float64 `json:"quality"`
+		} `json:"results"`
+	}
+	if err := getJSON(endpoint, url.Values{"count": {fmt.Sprint(count)}}, nil, &resp); err != nil {
+		return nil, err
+	}
+
+	page := &Page{
+		PageNum: pageNum,
+		HasMore: len(resp.Results) == count && count < marginaliaMaxResults,
+	}
+	start := (pageNum - 1) * marginaliaPageSize
+	for i := start; i < len(resp.Results); i++ {
+		r := resp.Results[i]
+		if r.Title == "" {
+			r.Title = r.URL
+		}
+		page.Results = append(page.Results, Result{
+			Title:   r.Title,
+			URL:     r.URL,
+			Snippet: r.Description,
+			Meta:    []Field{{Name: "Quality", Value: fmt.Sprintf("%.1f", r.Quality)}},
+		})
+	}
+	return page, nil
+}
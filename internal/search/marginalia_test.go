@@ -0,0 +1,41 @@
+package search
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMarginalia(t *testing.T) {
+	srv, got := serveFixture(t, "marginalia.json")
+	m := &Marginalia{Endpoint: srv.URL + "/"}
+	page, err := m.fetch("go lang", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if (*got).Path != "/public/search/go lang" || (*got).Query().Get("count") != "20" {
+		t.Errorf("request = %s", *got)
+	}
+
+	// Page 2 is the tail of the 12 results returned
+	want := []Result{
+		{Title: "Site 11", URL: "https://example11.org/", Snippet: "Description 11", Meta: []Field{{"Quality", "5.5"}}},
+		{Title: "https://untitled.example/", URL: "https://untitled.example/", Meta: []Field{{"Quality", "0.3"}}},
+	}
+	if !slices.EqualFunc(page.Results, want, resultEqual) {
+		t.Errorf("results = %+v\nwant %+v", page.Results, want)
+	}
+	if page.HasMore {
+		t.Error("short result list has more")
+	}
+}
+
+func TestMarginaliaKey(t *testing.T) {
+	srv, got := serveFixture(t, "marginalia.json")
+	m := &Marginalia{Key: "secret", Endpoint: srv.URL + "/"}
+	if _, err := m.Search("go"); err != nil {
+		t.Fatal(err)
+	}
+	if (*got).Path != "/secret/search/go" {
+		t.Errorf("path = %q", (*got).Path)
+	}
+}
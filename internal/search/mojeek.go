@@ -0,0 +1,26 @@
+package search
+
+func init() {
+	RegisterRegional("mojeek", []string{"mj"}, mapRegions(mojeekRegionMap), func(o Options) (Backend, error) {
+		return NewScraper(BuiltinScrapers["mojeek"], o)
+	})
+}
+
+// mojeekRegionMap maps region codes to Mojeek's arc parameter.
+var mojeekRegionMap = map[string]string{
+	"jp": "jp",
+	"us": "us",
+	"gb": "gb",
+	"de": "de",
+	"fr": "fr",
+	"es": "es",
+	"it": "it",
+	"br": "br",
+	"ca": "ca",
+	"au": "au",
+	"in": "in",
+	"kr": "kr",
+	"cn": "cn",
+	"tw": "tw",
+	"ru": "ru",
+}
@@ -0,0 +1,68 @@
+package search
+
+import (
+	"net/url"
+	"slices"
+	"testing"
+)
+
+func mojeekScraper(t *testing.T) *Scraper {
+	t.Helper()
+	s, err := NewScraper(BuiltinScrapers["mojeek"], Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestMojeekParse(t *testing.T) {
+	base, _ := url.Parse("https://www.mojeek.com/search?q=golang&s=11")
+	page := mojeekScraper(t).parse(fixtureDoc(t, "mojeek.html"), base, 2)
+
+	want := []Result{
+		{
+			Title:   "The Go Programming Language",
+			URL:     "https://go.dev/",
+			Snippet: "Go is an open source programming language that makes it simple to build secure, scalable systems.",
+		},
+		{
+			Title:   "Go (programming language) - Wikipedia",
+			URL:     "https://en.wikipedia.org/wiki/Go_(programming_language)",
+			Snippet: "Go is a high-level general purpose programming language.",
+		},
+		{Title: "Relative link", URL: "https://www.mojeek.com/about"},
+	}
+	if !slices.EqualFunc(page.Results, want, resultEqual) {
+		t.Errorf("results = %+v\nwant %+v", page.Results, want)
+	}
+	if !page.HasMore {
+		t.Error("next link ignored")
+	}
+}
+
+func TestMojeekLastPage(t *testing.T) {
+	// Only the links back to earlier pages are left
+	doc := docFromString(t, `<ul class="results-standard"><li><h2><a href="https://go.dev/">Go</a></h2></li></ul>
+		<div class="pagination"><ul>
+		<li><a href="/search?q=golang&amp;s=11" title="Previous page">Prev</a></li>
+		<li><a href="/search?q=golang&amp;s=1">1</a></li>
+		<li><a href="/search?q=golang&amp;s=11">2</a></li>
+		<li class="current">3</li>
+		</ul></div>`)
+	base, _ := url.Parse("https://www.mojeek.com/search?q=golang&s=21")
+	page := mojeekScraper(t).parse(doc, base, 3)
+	if len(page.Results) != 1 || page.HasMore {
+		t.Errorf("%d results, has more %v", len(page.Results), page.HasMore)
+	}
+}
+
+func TestMojeekParams(t *testing.T) {
+	s, err := NewScraper(BuiltinScrapers["mojeek"], Options{Region: "jp", Language: "ja-JP"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := s.params("golang", 3)
+	if p.Get("s") != "21" || p.Get("arc") != "jp" || p.Get("lb") != "ja" {
+		t.Errorf("params = %v", p)
+	}
+}
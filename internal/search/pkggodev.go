@@ -0,0 +1,116 @@
+package search
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func init() {
+	Register("pkggodev", []string{"godoc", "pkg"}, func(o Options) (Backend, error) {
+		return &PkgGoDev{}, nil
+	})
+}
+
+// PkgGoDev searches Go packages on pkg.go.dev. The site has no public search
+// API, so the HTML results page is parsed.
+type PkgGoDev struct {
+	Endpoint string // overrides the search page URL
+}
+
+const (
+	pkgGoDevEndpoint = "https://pkg.go.dev/search"
+	pkgGoDevPageSize = 25
+)
+
+func (p *PkgGoDev) Name() string { return "pkggodev" }
+
+func (p *PkgGoDev) Search(query string) (*Page, error) {
+	return p.fetch(query, 1)
+}
+
+func (p *PkgGoDev) NextPage(prev *Page, query string) (*Page, error) {
+	if !prev.HasMore {
+		return nil, fmt.Errorf("no more pages")
+	}
+	return p.fetch(query, prev.PageNum+1)
+}
+
+func (p *PkgGoDev) PrevPage(query string, pageNum int) (*Page, error) {
+	return p.fetch(query, max(1, pageNum))
+}
+
+func (p *PkgGoDev) fetch(query string, pageNum int) (*Page, error) {
+	params := url.Values{
+		"q":     {query},
+		"m":     {"package"},
+		"limit": {fmt.Sprint(pkgGoDevPageSize)},
+	}
+	if pageNum > 1 {
+		params.Set("page", fmt.Sprint(pageNum))
+	}
+
+	req, err := http.NewRequest("GET", cmp.Or(p.Endpoint, pkgGoDevEndpoint)+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("User-Agent", apiUserAgent)
+
+	resp, err := apiClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("performing search: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("search returned status %d", resp.StatusCode)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("parsing response: %w", err)
+	}
+
+	page := &Page{PageNum: pageNum}
+	doc.Find(".SearchSnippet").Each(func(i int, s *goquery.Selection) {
+		link := s.Find(".SearchSnippet-headerContainer a, h2 a").First()
+		href, _ := link.Attr("href")
+		if href == "" {
+			return
+		}
+		importPath := strings.Trim(strings.TrimSpace(s.Find(".SearchSnippet-header-path").First().Text()), "()")
+		if importPath == "" {
+			importPath = strings.TrimPrefix(href, "/")
+		}
+		name := strings.TrimSpace(link.Contents().First().Text())
+		if name == "" {
+			name = importPath
+		}
+
+		r := Result{
+			Title:   name,
+			URL:     "https://pkg.go.dev" + href,
+			Snippet: strings.TrimSpace(s.Find(".SearchSnippet-synopsis").First().Text()),
+			Meta:    []Field{{Name: "Import", Value: importPath}},
+		}
+		// Info labels look like "Imported by 1,234 | v1.2.3 published on Jan 2, 2024 | MIT"
+		s.Find(".SearchSnippet-infoLabel").Children().Each(func(i int, l *goquery.Selection) {
+			text := strings.Join(strings.Fields(l.Text()), " ")
+			switch {
+			case strings.HasPrefix(text, "Imported by"):
+				r.Meta = append(r.Meta, Field{Name: "Imported by", Value: strings.TrimSpace(strings.TrimPrefix(text, "Imported by"))})
+			case strings.Contains(text, "published on"):
+				version, date, _ := strings.Cut(text, " published on ")
+				r.Meta = append(r.Meta, Field{Name: "Version", Value: version}, Field{Name: "Published", Value: date})
+			}
+		})
+		page.Results = append(page.Results, r)
+	})
+
+	page.HasMore = doc.Find(`a[aria-label="Go to next page"], .Pagination-next:not([aria-disabled="true"])`).Length() > 0
+	return page, nil
+}
@@ -0,0 +1,47 @@
+package search
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPkgGoDev(t *testing.T) {
+	srv, got := serveFixture(t, "pkggodev.html")
+	p := &PkgGoDev{Endpoint: srv.URL}
+	page, err := p.Search("uuid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q := (*got).Query(); q.Get("q") != "uuid" || q.Has("page") {
+		t.Errorf("query = %v", q)
+	}
+
+	want := []Result{
+		{
+			Title:   "uuid",
+			URL:     "https://pkg.go.dev/github.com/google/uuid",
+			Snippet: "Package uuid generates and inspects UUIDs.",
+			Meta: []Field{{"Import", "github.com/google/uuid"}, {"Imported by", "98,765"},
+				{"Version", "v1.6.0"}, {"Published", "Jan 23, 2024"}},
+		},
+		{
+			Title:   "uuid",
+			URL:     "https://pkg.go.dev/github.com/gofrs/uuid/v5",
+			Snippet: "Package uuid provides implementations of the Universally Unique Identifier (UUID).",
+			Meta:    []Field{{"Import", "github.com/gofrs/uuid/v5"}},
+		},
+	}
+	if !slices.EqualFunc(page.Results, want, resultEqual) {
+		t.Errorf("results = %+v\nwant %+v", page.Results, want)
+	}
+	if !page.HasMore {
+		t.Error("next page link ignored")
+	}
+
+	if _, err := p.PrevPage("uuid", 2); err != nil {
+		t.Fatal(err)
+	}
+	if (*got).Query().Get("page") != "2" {
+		t.Errorf("page = %q, want 2", (*got).Query().Get("page"))
+	}
+}
@@ -0,0 +1,157 @@
+package search
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Region describes a region, keyed by ISO 3166-1 alpha-2 code.
+type Region struct {
+	Code     string // lower case, e.g. "jp", "gb"
+	Name     string
+	Language string // main BCP 47 language tag, e.g. "ja-JP"
+}
+
+// regionInfo names regions and their main language. Which regions exist is
+// up to the engines' region maps; a code missing here is shown without a
+// name.
+var regionInfo = []Region{
+	{"ar", "Argentina", "es-AR"},
+	{"at", "Austria", "de-AT"},
+	{"au", "Australia", "en-AU"},
+	{"be", "Belgium", "nl-BE"},
+	{"br", "Brazil", "pt-BR"},
+	{"ca", "Canada", "en-CA"},
+	{"ch", "Switzerland", "de-CH"},
+	{"cl", "Chile", "es-CL"},
+	{"cn", "China", "zh-CN"},
+	{"de", "Germany", "de-DE"},
+	{"dk", "Denmark", "da-DK"},
+	{"es", "Spain", "es-ES"},
+	{"fi", "Finland", "fi-FI"},
+	{"fr", "France", "fr-FR"},
+	{"gb", "United Kingdom", "en-GB"},
+	{"hk", "Hong Kong", "zh-HK"},
+	{"id", "Indonesia", "id-ID"},
+	{"ie", "Ireland", "en-IE"},
+	{"in", "India", "en-IN"},
+	{"it", "Italy", "it-IT"},
+	{"jp", "Japan", "ja-JP"},
+	{"kr", "South Korea", "ko-KR"},
+	{"mx", "Mexico", "es-MX"},
+	{"nl", "Netherlands", "nl-NL"},
+	{"no", "Norway", "nb-NO"},
+	{"nz", "New Zealand", "en-NZ"},
+	{"pl", "Poland", "pl-PL"},
+	{"pt", "Portugal", "pt-PT"},
+	{"ru", "Russia", "ru-RU"},
+	{"se", "Sweden", "sv-SE"},
+	{"tr", "Turkey", "tr-TR"},
+	{"tw", "Taiwan", "zh-TW"},
+	{"us", "United States", "en-US"},
+	{"za", "South Africa", "en-ZA"},
+}
+
+// regionAliases accepts common codes that are not ISO 3166-1.
+var regionAliases = map[string]string{"uk": "gb"}
+
+// Regions returns the regions supported by at least one registered engine,
+// sorted by code.
+func Regions() []Region {
+	var codes []string
+	for _, e := range engines {
+		codes = append(codes, e.regions...)
+	}
+	slices.Sort(codes)
+	out := make([]Region, 0, len(codes))
+	for _, c := range slices.Compact(codes) {
+		r, err := LookupRegion(c)
+		if err != nil {
+			r = Region{Code: c}
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// LookupRegion finds the name and language of a region code, ignoring case
+// and accepting aliases such as "uk".
+func LookupRegion(code string) (Region, error) {
+	c := normalizeRegion(code)
+	i := slices.IndexFunc(regionInfo, func(r Region) bool { return r.Code == c })
+	if i < 0 {
+		return Region{}, fmt.Errorf("unknown region %q (see ksk regions)", code)
+	}
+	return regionInfo[i], nil
+}
+
+// normalizeRegion lowercases a region code and resolves aliases.
+func normalizeRegion(code string) string {
+	c := strings.ToLower(strings.TrimSpace(code))
+	if a, ok := regionAliases[c]; ok {
+		return a
+	}
+	return c
+}
+
+// mapRegions lists the region codes of an engine's parameter map, sorted.
+func mapRegions(m map[string]string) []string {
+	return slices.Sorted(maps.Keys(m))
+}
+
+// ParseLanguage validates a BCP 47 language tag such as "ja", "en-GB" or
+// "zh-Hant-TW" and returns it in canonical case.
+func ParseLanguage(tag string) (string, error) {
+	parts := strings.Split(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"), "-")
+	if len(parts[0]) < 2 || len(parts[0]) > 3 || !isAlpha(parts[0]) {
+		return "", fmt.Errorf("invalid language tag %q (e.g. en, ja, pt-BR)", tag)
+	}
+	parts[0] = strings.ToLower(parts[0])
+	for i, p := range parts[1:] {
+		switch {
+		case len(p) == 2 && isAlpha(p):
+			parts[i+1] = strings.ToUpper(p) // region
+		case len(p) == 4 && isAlpha(p):
+			parts[i+1] = strings.ToUpper(p[:1]) + strings.ToLower(p[1:]) // script
+		case len(p) >= 1 && len(p) <= 8 && isAlnum(p):
+			parts[i+1] = strings.ToLower(p)
+		default:
+			return "", fmt.Errorf("invalid language tag %q (e.g. en, ja, pt-BR)", tag)
+		}
+	}
+	return strings.Join(parts, "-"), nil
+}
+
+// primaryLanguage returns the language subtag of tag, e.g. "pt" for "pt-BR".
+func primaryLanguage(tag string) string {
+	lang, _, _ := strings.Cut(tag, "-")
+	return lang
+}
+
+// acceptLanguage builds an Accept-Language header preferring tag, falling
+// back to English.
+func acceptLanguage(tag string) string {
+	if tag == "" {
+		return "en-US,en;q=0.5"
+	}
+	values := []string{tag}
+	if p := primaryLanguage(tag); p != tag {
+		values = append(values, p+";q=0.9")
+	}
+	if primaryLanguage(tag) != "en" {
+		values = append(values, "en;q=0.5")
+	}
+	return strings.Join(values, ",")
+}
+
+func isAlpha(s string) bool {
+	return strings.IndexFunc(s, func(r rune) bool { return (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') }) < 0
+}
+
+func isAlnum(s string) bool {
+	return strings.IndexFunc(s, func(r rune) bool {
+		return (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9')
+	}) < 0
+}
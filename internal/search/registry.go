@@ -0,0 +1,131 @@
+package search
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Options are passed to backend constructors.
+type Options struct {
+	Region     string     // ISO 3166-1 code, see Regions
+	Language   string     // BCP 47 tag; "" leaves the engine's default
+	SafeSearch SafeSearch // "" leaves the engine's default
+}
+
+// SafeSearch is an adult-content filtering level.
+type SafeSearch string
+
+const (
+	SafeOff      SafeSearch = "off"
+	SafeModerate SafeSearch = "moderate"
+	SafeStrict   SafeSearch = "strict"
+)
+
+// ParseSafeSearch validates a filtering level name.
+func ParseSafeSearch(s string) (SafeSearch, error) {
+	switch v := SafeSearch(strings.ToLower(s)); v {
+	case SafeOff, SafeModerate, SafeStrict:
+		return v, nil
+	}
+	return "", fmt.Errorf("invalid safe search level %q (use off, moderate, strict)", s)
+}
+
+// Factory creates a backend from options.
+type Factory func(opts Options) (Backend, error)
+
+type engine struct {
+	name    string
+	aliases []string
+	regions []string // supported region codes; nil when any is accepted
+	factory Factory
+}
+
+var engines []engine
+
+func init() {
+	RegisterRegional("duckduckgo", []string{"ddg"}, mapRegions(ddgRegionMap), func(o Options) (Backend, error) {
+		return &DuckDuckGo{Region: o.Region, Language: o.Language, SafeSearch: o.SafeSearch}, nil
+	})
+	RegisterRegional("brave", []string{"b"}, mapRegions(braveRegionMap), func(o Options) (Backend, error) {
+		return &Brave{Region: o.Region, Language: o.Language, SafeSearch: o.SafeSearch}, nil
+	})
+}
+
+// Register makes a backend available to New under name and its aliases.
+// Registering a name twice replaces the earlier factory.
+func Register(name string, aliases []string, f Factory) {
+	RegisterRegional(name, aliases, nil, f)
+}
+
+// RegisterRegional is Register for an engine that supports only the given
+// region codes, which EngineRegions reports without creating a backend.
+func RegisterRegional(name string, aliases, regions []string, f Factory) {
+	e := engine{name, aliases, regions, f}
+	for i := range engines {
+		if engines[i].name == name {
+			engines[i] = e
+			return
+		}
+	}
+	engines = append(engines, e)
+}
+
+// EngineRegions returns the canonical name of the engine registered under
+// name or one of its aliases, and the region codes it supports. No codes
+// means any region is accepted.
+func EngineRegions(name string) (string, []string, error) {
+	i := slices.IndexFunc(engines, func(e engine) bool {
+		return e.name == name || slices.Contains(e.aliases, name)
+	})
+	if i < 0 {
+		return "", nil, fmt.Errorf("unknown engine: %s (use %s)", name, strings.Join(Engines(), ", "))
+	}
+	return engines[i].name, engines[i].regions, nil
+}
+
+// New creates the backend registered under name or one of its aliases.
+// The region and language are validated first, and a region the backend
+// does not support is an error.
+func New(name string, opts Options) (Backend, error) {
+	i := slices.IndexFunc(engines, func(e engine) bool {
+		return e.name == name || slices.Contains(e.aliases, name)
+	})
+	if i < 0 {
+		return nil, fmt.Errorf("unknown engine: %s (use %s)", name, strings.Join(Engines(), ", "))
+	}
+	// Engines without a region list pass any code through
+	if opts.Region != "" {
+		opts.Region = normalizeRegion(opts.Region)
+		if e := engines[i]; len(e.regions) > 0 && !slices.Contains(e.regions, opts.Region) {
+			return nil, fmt.Errorf("%s does not support region %s (supported: %s)", e.name, opts.Region, strings.Join(e.regions, ", "))
+		}
+	}
+	if opts.Language != "" {
+		l, err := ParseLanguage(opts.Language)
+		if err != nil {
+			return nil, err
+		}
+		opts.Language = l
+	}
+
+	return engines[i].factory(opts)
+}
+
+// Registered reports whether name is the name or an alias of a registered
+// engine.
+func Registered(name string) bool {
+	return slices.ContainsFunc(engines, func(e engine) bool {
+		return e.name == name || slices.Contains(e.aliases, name)
+	})
+}
+
+// Engines returns the registered engine names, sorted.
+func Engines() []string {
+	names := make([]string, len(engines))
+	for i, e := range engines {
+		names[i] = e.name
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,50 @@
+package search
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestEngineRegions(t *testing.T) {
+	if name, codes, err := EngineRegions("mj"); err != nil || name != "mojeek" || !slices.Contains(codes, "jp") {
+		t.Errorf("EngineRegions(mj) = %q, %v, %v", name, codes, err)
+	}
+	if _, codes, err := EngineRegions("wikipedia"); err != nil || codes != nil {
+		t.Errorf("EngineRegions(wikipedia) = %v, %v, want any region", codes, err)
+	}
+	if _, _, err := EngineRegions("nope"); err == nil {
+		t.Error("EngineRegions(nope) succeeded")
+	}
+}
+
+func TestNewRegion(t *testing.T) {
+	b, err := New("brave", Options{Region: "UK"})
+	if err != nil || b.(*Brave).Region != "gb" {
+		t.Errorf("New(brave, UK) = %+v, %v", b, err)
+	}
+	if _, err := New("mojeek", Options{Region: "nz"}); err == nil {
+		t.Error("unsupported region accepted")
+	}
+	// Engines without a region list get any code, even one without a name
+	b, err = New("wikipedia", Options{Region: "VN"})
+	if err != nil || b.(*Wikipedia).Region != "vn" {
+		t.Errorf("New(wikipedia, VN) = %+v, %v", b, err)
+	}
+}
+
+func TestRegions(t *testing.T) {
+	regions := Regions()
+	if !slices.IsSortedFunc(regions, func(a, b Region) int { return strings.Compare(a.Code, b.Code) }) {
+		t.Error("regions not sorted")
+	}
+	if i := slices.IndexFunc(regions, func(r Region) bool { return r.Code == "jp" }); i < 0 || regions[i].Name != "Japan" {
+		t.Errorf("jp missing or unnamed: %v", regions)
+	}
+	// Every listed region is supported by some engine
+	for _, r := range regions {
+		if !slices.ContainsFunc(engines, func(e engine) bool { return slices.Contains(e.regions, r.Code) }) {
+			t.Errorf("%s is not supported by any engine", r.Code)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package search
+
+import (
+	"encoding/base64"
+	"net/url"
+	"strings"
+)
+
+// Sanitizer cleans result URLs before they are opened or copied.
+type Sanitizer struct {
+	// StripParams lists query parameters to drop. A trailing "*" matches
+	// any parameter with that prefix (e.g. "utm_*").
+	StripParams []string
+	// Frontends maps a host (and its subdomains) to a replacement host,
+	// e.g. "youtube.com" -> "yewtu.be".
+	Frontends map[string]string
+}
+
+// DefaultStripParams are the tracking parameters removed when no custom
+// rule list is configured.
+var DefaultStripParams = []string{
+	"utm_*",
+	"fbclid",
+	"gclid",
+	"gclsrc",
+	"dclid",
+	"gbraid",
+	"wbraid",
+	"msclkid",
+	"yclid",
+	"twclid",
+	"igshid",
+	"mc_cid",
+	"mc_eid",
+	"_hsenc",
+	"_hsmi",
+	"mkt_tok",
+	"oly_anon_id",
+	"oly_enc_id",
+	"vero_id",
+	"ref_src",
+}
+
+// NewSanitizer returns a Sanitizer using DefaultStripParams and no frontends.
+func NewSanitizer() *Sanitizer {
+	return &Sanitizer{StripParams: DefaultStripParams}
+}
+
+// redirector describes a click-tracking URL that carries its destination
+// in a query parameter.
+type redirector struct {
+	host   string
+	path   string // path prefix
+	params []string
+}
+
+var redirectors = []redirector{
+	{"duckduckgo.com", "/l/", []string{"uddg"}},
+	{"duckduckgo.com", "/y.js", []string{"u3", "u"}},
+	{"bing.com", "/aclick", []string{"u"}},
+	{"bing.com", "/ck/a", []string{"u"}},
+	{"search.brave.com", "/a/redirect", []string{"click_url", "url"}},
+	{"google.com", "/url", []string{"q", "url"}},
+	{"l.facebook.com", "/l.php", []string{"u"}},
+	{"out.reddit.com", "/", []string{"url"}},
+}
+
+// Clean unwraps redirectors, strips tracking parameters and applies
+// frontend rewrites. Unparseable URLs are returned unchanged.
+func (s *Sanitizer) Clean(rawURL string) string {
+	u, err := url.Parse(Unwrap(rawURL))
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+
+	if u.RawQuery != "" {
+		var kept []string
+		for _, kv := range strings.Split(u.RawQuery, "&") {
+			name, _, _ := strings.Cut(kv, "=")
+			if name, err := url.QueryUnescape(name); err == nil && s.strip(name) {
+				continue
+			}
+			kept = append(kept, kv)
+		}
+		u.RawQuery = strings.Join(kept, "&")
+	}
+
+	if host, ok := matchHost(s.Frontends, u.Hostname()); ok {
+		if port := u.Port(); port != "" {
+			host += ":" + port
+		}
+		u.Host = host
+	}
+
+	return u.String()
+}
+
+func (s *Sanitizer) strip(name string) bool {
+	for _, p := range s.StripParams {
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Unwrap follows known redirector URLs to their destination. It is safe to
+// call on any URL; non-redirector URLs are returned unchanged.
+func Unwrap(rawURL string) string {
+	// Nested redirectors (e.g. DDG ad -> Bing click) are followed a few levels deep
+	for range 4 {
+		next, ok := unwrapOnce(rawURL)
+		if !ok {
+			break
+		}
+		rawURL = next
+	}
+	return rawURL
+}
+
+func unwrapOnce(rawURL string) (string, bool) {
+	if strings.HasPrefix(rawURL, "//") {
+		rawURL = "https:" + rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", false
+	}
+	host := u.Hostname()
+	for _, r := range redirectors {
+		if !hostMatches(host, r.host) || !strings.HasPrefix(u.Path, r.path) {
+			continue
+		}
+		q := u.Query()
+		for _, p := range r.params {
+			if dest := decodeTarget(q.Get(p)); dest != "" {
+				return dest, true
+			}
+		}
+	}
+	return "", false
+}
+
+// decodeTarget accepts a destination as either a plain URL or Bing's
+// "a1"-prefixed base64 form.
+func decodeTarget(v string) string {
+	if strings.HasPrefix(v, "http://") || strings.HasPrefix(v, "https://") {
+		return v
+	}
+	if rest, ok := strings.CutPrefix(v, "a1"); ok {
+		b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(rest, "="))
+		if err == nil && strings.HasPrefix(string(b), "http") {
+			return string(b)
+		}
+	}
+	return ""
+}
+
+func hostMatches(host, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// matchHost returns the replacement for the longest domain of m that host
+// is or is a subdomain of, so "m.youtube.com" wins over "youtube.com".
+func matchHost(m map[string]string, host string) (string, bool) {
+	best := ""
+	for domain := range m {
+		if hostMatches(host, domain) && len(domain) > len(best) {
+			best = domain
+		}
+	}
+	if best == "" {
+		return "", false
+	}
+	return m[best], true
+}
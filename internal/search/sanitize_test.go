@@ -0,0 +1,57 @@
+package search
+
+import "testing"
+
+func TestSanitizerClean(t *testing.T) {
+	s := &Sanitizer{
+		StripParams: DefaultStripParams,
+		Frontends: map[string]string{
+			"youtube.com":   "yewtu.be",
+			"m.youtube.com": "invidious.example",
+			"reddit.com":    "old.reddit.com",
+		},
+	}
+	tests := []struct {
+		name, in, want string
+	}{
+		{"utm prefix", "https://go.dev/?utm_source=x&utm_medium=y", "https://go.dev/"},
+		{"kept params", "https://go.dev/doc?q=generics&fbclid=abc&page=2", "https://go.dev/doc?q=generics&page=2"},
+		{"escaped name", "https://go.dev/?%67clid=abc&a=1", "https://go.dev/?a=1"},
+		{"fragment", "https://go.dev/?gclid=1#top", "https://go.dev/#top"},
+		{"no query", "https://go.dev/doc", "https://go.dev/doc"},
+		{"frontend", "https://www.youtube.com/watch?v=1", "https://yewtu.be/watch?v=1"},
+		{"longest frontend", "https://m.youtube.com/watch?v=1", "https://invidious.example/watch?v=1"},
+		{"frontend port", "https://reddit.com:8443/r/golang", "https://old.reddit.com:8443/r/golang"},
+		{"similar host", "https://notyoutube.com/", "https://notyoutube.com/"},
+		{"redirector", "https://duckduckgo.com/l/?uddg=https%3A%2F%2Fgo.dev%2F%3Futm_source%3Dddg", "https://go.dev/"},
+		{"relative", "/about", "/about"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Map order varies between runs; the result must not
+			for range 20 {
+				if got := s.Clean(tt.in); got != tt.want {
+					t.Fatalf("Clean(%q) = %q, want %q", tt.in, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestUnwrap(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"ddg", "//duckduckgo.com/l/?uddg=https%3A%2F%2Fgo.dev%2F&rut=abc", "https://go.dev/"},
+		{"bing base64", "https://www.bing.com/ck/a?u=a1aHR0cHM6Ly9nby5kZXYv", "https://go.dev/"},
+		{"nested", "https://duckduckgo.com/y.js?u3=https%3A%2F%2Fwww.bing.com%2Faclick%3Fu%3Dhttps%253A%252F%252Fgo.dev%252F", "https://go.dev/"},
+		{"google", "https://www.google.com/url?q=https://go.dev/&sa=U", "https://go.dev/"},
+		{"not a redirector", "https://go.dev/l/?uddg=https%3A%2F%2Fexample.com", "https://go.dev/l/?uddg=https%3A%2F%2Fexample.com"},
+		{"no destination", "https://duckduckgo.com/l/?uddg=javascript%3Aalert(1)", "https://duckduckgo.com/l/?uddg=javascript%3Aalert(1)"},
+	}
+	for _, tt := range tests {
+		if got := Unwrap(tt.in); got != tt.want {
+			t.Errorf("%s: Unwrap(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,420 @@
+package search
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ScraperConfig declares an HTML search engine: how to build the request,
+// where results live in the page and how to reach the next page.
+type ScraperConfig struct {
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases,omitempty"`
+	// Extends names a built-in definition (see BuiltinScrapers) whose
+	// fields are used wherever this one leaves them empty.
+	Extends string `json:"extends,omitempty"`
+
+	Method string `json:"method,omitempty"` // GET (default) or POST
+	URL    string `json:"url,omitempty"`
+	// Params are request parameters. Values may contain the placeholders
+	// {query}, {page}, {offset}, {region}, {language} (BCP 47 tag) and
+	// {lang} (its language subtag); parameters that expand to "" are
+	// omitted.
+	Params  map[string]string `json:"params,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// Regions maps ksk region codes to the engine's {region} value.
+	Regions map[string]string `json:"regions,omitempty"`
+
+	Selectors  ScraperSelectors  `json:"selectors"`
+	Pagination ScraperPagination `json:"pagination"`
+
+	// UnwrapParam names a query parameter holding the real destination
+	// when result links go through the engine's own redirector.
+	UnwrapParam string `json:"unwrap_param,omitempty"`
+}
+
+// ScraperSelectors are CSS selectors, evaluated relative to each result
+// except Result itself.
+type ScraperSelectors struct {
+	Result  string `json:"result,omitempty"`
+	Title   string `json:"title,omitempty"`
+	URL     string `json:"url,omitempty"`      // defaults to Title
+	URLAttr string `json:"url_attr,omitempty"` // defaults to "href"
+	Snippet string `json:"snippet,omitempty"`
+	Ad      string `json:"ad,omitempty"` // matches the result itself or a descendant
+}
+
+// ScraperPagination describes how the next page is requested.
+type ScraperPagination struct {
+	// Type is "offset" (default), "page", "form" or "none".
+	//   offset: {offset} = Start + (page-1)*Step
+	//   page:   {page} counts from Start (default 1)
+	//   form:   hidden inputs of the Next form are submitted
+	Type  string `json:"type,omitempty"`
+	Start int    `json:"start,omitempty"`
+	Step  int    `json:"step,omitempty"`
+	// Next selects the element proving a next page exists (or the form, for
+	// type "form"). When empty, any page with results is assumed to have one.
+	Next string `json:"next,omitempty"`
+}
+
+// BuiltinScrapers holds the built-in engines as scraper definitions, usable
+// as "extends" bases when a site changes its markup. Mojeek is driven by its
+// definition; DuckDuckGo and Brave have hand-written backends that also
+// parse answers and corrections, and their definitions cover the results
+// only.
+var BuiltinScrapers = map[string]ScraperConfig{
+	"duckduckgo": {
+		Name:   "duckduckgo",
+		Method: "POST",
+		URL:    ddgEndpoint,
+		Params: map[string]string{"q": "{query}", "kl": "{region}"},
+		Headers: map[string]string{
+			"Content-Type": "application/x-www-form-urlencoded",
+			"Referer":      "https://html.duckduckgo.com/",
+		},
+		Regions: ddgRegionMap,
+		Selectors: ScraperSelectors{
+			Result:  ".result.results_links",
+			Title:   ".result__title a.result__a",
+			Snippet: ".result__snippet",
+			Ad:      ".result--ad",
+		},
+		Pagination:  ScraperPagination{Type: "form", Next: ".nav-link form"},
+		UnwrapParam: "uddg",
+	},
+	"brave": {
+		Name:    "brave",
+		URL:     braveEndpoint,
+		Params:  map[string]string{"q": "{query}", "source": "web", "country": "{region}", "offset": "{offset}"},
+		Regions: braveRegionMap,
+		Selectors: ScraperSelectors{
+			Result:  `div.snippet[data-type="web"], div.snippet[data-type="ad"]`,
+			Title:   "div.search-snippet-title, a.title",
+			URL:     "a[href^='http']",
+			Snippet: "div.generic-snippet .content, div.description, p.snippet-description",
+			Ad:      `[data-type="ad"]`,
+		},
+		// offset is a 0-based page number; the link back to the previous
+		// page also carries one, so only the "Next" link counts
+		Pagination: ScraperPagination{Type: "offset", Step: 1, Next: "#pagination a:contains('Next')"},
+	},
+	"mojeek": {
+		Name: "mojeek",
+		URL:  "https://www.mojeek.com/search",
+		// s is the 1-based index of the first result; arc restricts to a
+		// region and lb biases towards a language
+		Params:  map[string]string{"q": "{query}", "s": "{offset}", "arc": "{region}", "lb": "{lang}"},
+		Regions: mojeekRegionMap,
+		Selectors: ScraperSelectors{
+			Result:  "ul.results-standard > li",
+			Title:   "h2 a, a.title",
+			Snippet: "p.s",
+		},
+		// The pagination also links back to earlier pages, so only the
+		// "Next" link counts
+		Pagination: ScraperPagination{Type: "offset", Start: 1, Step: 10, Next: ".pagination a.next, .pagination a[rel='next'], .pagination a[title^='Next']"},
+	},
+}
+
+// scraperHeaders are sent unless the definition overrides them.
+var scraperHeaders = http.Header{
+	"User-Agent":      {"Mozilla/5.0 (X11; Linux x86_64; rv:138.0) Gecko/20100101 Firefox/138.0"},
+	"Accept":          {"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"},
+	"Accept-Language": {"en-US,en;q=0.5"},
+}
+
+// Scraper is a Backend driven by a ScraperConfig.
+type Scraper struct {
+	cfg      ScraperConfig
+	region   string
+	language string
+	client   *http.Client
+}
+
+// NewScraper validates cfg, resolving Extends, and returns a backend for it.
+func NewScraper(cfg ScraperConfig, opts Options) (*Scraper, error) {
+	if cfg.Extends != "" {
+		base, ok := BuiltinScrapers[cfg.Extends]
+		if !ok {
+			return nil, fmt.Errorf("scraper %s: unknown base %q", cfg.Name, cfg.Extends)
+		}
+		cfg = mergeScraper(base, cfg)
+	}
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("scraper needs a name")
+	}
+	if cfg.URL == "" || cfg.Selectors.Result == "" || cfg.Selectors.Title == "" {
+		return nil, fmt.Errorf("scraper %s: url, selectors.result and selectors.title are required", cfg.Name)
+	}
+	if cfg.Method == "" {
+		cfg.Method = "GET"
+	}
+	cfg.Method = strings.ToUpper(cfg.Method)
+	if cfg.Method != "GET" && cfg.Method != "POST" {
+		return nil, fmt.Errorf("scraper %s: unsupported method %s", cfg.Name, cfg.Method)
+	}
+	switch cfg.Pagination.Type {
+	case "":
+		cfg.Pagination.Type = "offset"
+	case "offset", "page", "form", "none":
+	default:
+		return nil, fmt.Errorf("scraper %s: unknown pagination type %q", cfg.Name, cfg.Pagination.Type)
+	}
+	if cfg.Pagination.Type == "page" && cfg.Pagination.Start == 0 {
+		cfg.Pagination.Start = 1
+	}
+	if cfg.Pagination.Type == "offset" && cfg.Pagination.Step == 0 {
+		cfg.Pagination.Step = 10
+	}
+
+	// Accept aliases such as "uk" as region map keys
+	regions := make(map[string]string, len(cfg.Regions))
+	for k, v := range cfg.Regions {
+		regions[normalizeRegion(k)] = v
+	}
+	cfg.Regions = regions
+
+	jar, _ := cookiejar.New(nil)
+	return &Scraper{cfg: cfg, region: opts.Region, language: opts.Language, client: &http.Client{Jar: jar}}, nil
+}
+
+// SupportedRegions lists the keys of the definition's region map, to
+// register the engine with. Without one, any region is passed through as
+// is.
+func (s *Scraper) SupportedRegions() []string { return mapRegions(s.cfg.Regions) }
+
+// mergeScraper fills empty fields of over from base.
+func mergeScraper(base, over ScraperConfig) ScraperConfig {
+	out := base
+	out.Name = over.Name
+	out.Aliases = over.Aliases
+	out.Extends = ""
+	if over.Method != "" {
+		out.Method = over.Method
+	}
+	if over.URL != "" {
+		out.URL = over.URL
+	}
+	if over.UnwrapParam != "" {
+		out.UnwrapParam = over.UnwrapParam
+	}
+	out.Params = mergeMap(base.Params, over.Params)
+	out.Headers = mergeMap(base.Headers, over.Headers)
+	out.Regions = mergeMap(base.Regions, over.Regions)
+
+	sel := &out.Selectors
+	for dst, src := range map[*string]string{
+		&sel.Result:  over.Selectors.Result,
+		&sel.Title:   over.Selectors.Title,
+		&sel.URL:     over.Selectors.URL,
+		&sel.URLAttr: over.Selectors.URLAttr,
+		&sel.Snippet: over.Selectors.Snippet,
+		&sel.Ad:      over.Selectors.Ad,
+	} {
+		if src != "" {
+			*dst = src
+		}
+	}
+	pag := &out.Pagination
+	if over.Pagination.Type != "" {
+		pag.Type = over.Pagination.Type
+	}
+	if over.Pagination.Start != 0 {
+		pag.Start = over.Pagination.Start
+	}
+	if over.Pagination.Step != 0 {
+		pag.Step = over.Pagination.Step
+	}
+	if over.Pagination.Next != "" {
+		pag.Next = over.Pagination.Next
+	}
+	return out
+}
+
+func mergeMap(base, over map[string]string) map[string]string {
+	out := maps.Clone(base)
+	if out == nil {
+		out = make(map[string]string)
+	}
+	maps.Copy(out, over)
+	return out
+}
+
+func (s *Scraper) Name() string { return s.cfg.Name }
+
+func (s *Scraper) Search(query string) (*Page, error) {
+	return s.fetch(query, 1, nil)
+}
+
+func (s *Scraper) NextPage(prev *Page, query string) (*Page, error) {
+	if !prev.HasMore {
+		return nil, fmt.Errorf("no more pages")
+	}
+	return s.fetch(query, prev.PageNum+1, prev.NextParams)
+}
+
+func (s *Scraper) PrevPage(query string, pageNum int) (*Page, error) {
+	return s.PrevPageContext(context.Background(), query, pageNum)
+}
+
+func (s *Scraper) PrevPageContext(ctx context.Context, query string, pageNum int) (*Page, error) {
+	if pageNum <= 1 || s.cfg.Pagination.Type != "form" {
+		return s.fetch(query, max(1, pageNum), nil)
+	}
+	// Form pagination can only move forward: replay from the first page
+	return replay(ctx, s, query, pageNum)
+}
+
+// params expands the configured parameters for pageNum.
+func (s *Scraper) params(query string, pageNum int) url.Values {
+	p := s.cfg.Pagination
+	region := s.region
+	if r, ok := s.cfg.Regions[region]; ok {
+		region = r
+	}
+	offset, page := 0, pageNum
+	switch p.Type {
+	case "offset":
+		offset = p.Start + (pageNum-1)*p.Step
+	case "page":
+		page = p.Start + pageNum - 1
+	}
+	repl := strings.NewReplacer(
+		"{query}", query,
+		"{page}", strconv.Itoa(page),
+		"{offset}", strconv.Itoa(offset),
+		"{region}", region,
+		"{language}", s.language,
+		"{lang}", primaryLanguage(s.language),
+	)
+
+	params := url.Values{}
+	for k, tmpl := range s.cfg.Params {
+		if v := repl.Replace(tmpl); v != "" {
+			params.Set(k, v)
+		}
+	}
+	return params
+}
+
+func (s *Scraper) fetch(query string, pageNum int, next url.Values) (*Page, error) {
+	params := s.params(query, pageNum)
+	for k, v := range next {
+		params[k] = v
+	}
+
+	var req *http.Request
+	var err error
+	if s.cfg.Method == "POST" {
+		req, err = http.NewRequest("POST", s.cfg.URL, strings.NewReader(params.Encode()))
+	} else {
+		req, err = http.NewRequest("GET", s.cfg.URL+"?"+params.Encode(), nil)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header = scraperHeaders.Clone()
+	req.Header.Set("Accept-Language", acceptLanguage(s.language))
+	if s.cfg.Method == "POST" {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	for k, v := range s.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("performing search: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		return nil, fmt.Errorf("rate limit triggered (status %d) — try again later", resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("search returned status %d", resp.StatusCode)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("parsing response: %w", err)
+	}
+	return s.parse(doc, req.URL, pageNum), nil
+}
+
+// parse extracts results and pagination state from a results page.
+func (s *Scraper) parse(doc *goquery.Document, base *url.URL, pageNum int) *Page {
+	sel := s.cfg.Selectors
+	urlSel := cmp.Or(sel.URL, sel.Title)
+	urlAttr := cmp.Or(sel.URLAttr, "href")
+
+	page := &Page{PageNum: pageNum}
+	doc.Find(sel.Result).Each(func(i int, r *goquery.Selection) {
+		title := strings.TrimSpace(r.Find(sel.Title).First().Text())
+		href, _ := r.Find(urlSel).First().Attr(urlAttr)
+		href = s.resolveURL(base, href)
+		if title == "" || href == "" {
+			return
+		}
+
+		res := Result{Title: title, URL: href}
+		if sel.Snippet != "" {
+			res.Snippet = strings.Join(strings.Fields(r.Find(sel.Snippet).First().Text()), " ")
+		}
+		if sel.Ad != "" && (r.Is(sel.Ad) || r.Find(sel.Ad).Length() > 0) {
+			res.Kind = KindAd
+		}
+		page.Results = append(page.Results, res)
+	})
+
+	p := s.cfg.Pagination
+	switch {
+	case p.Type == "none" || len(page.Results) == 0:
+	case p.Next == "":
+		page.HasMore = true
+	case p.Type == "form":
+		form := doc.Find(p.Next).First()
+		if form.Length() > 0 {
+			page.HasMore = true
+			page.NextParams = url.Values{}
+			form.Find("input[type='hidden']").Each(func(i int, in *goquery.Selection) {
+				name, _ := in.Attr("name")
+				val, _ := in.Attr("value")
+				if name != "" {
+					page.NextParams.Set(name, val)
+				}
+			})
+		}
+	default:
+		page.HasMore = doc.Find(p.Next).Length() > 0
+	}
+	return page
+}
+
+// resolveURL makes href absolute and unwraps the engine's redirector.
+func (s *Scraper) resolveURL(base *url.URL, href string) string {
+	href = strings.TrimSpace(href)
+	if href == "" {
+		return ""
+	}
+	u, err := base.Parse(href)
+	if err != nil {
+		return ""
+	}
+	if s.cfg.UnwrapParam != "" {
+		if dest := u.Query().Get(s.cfg.UnwrapParam); dest != "" {
+			return dest
+		}
+	}
+	return Unwrap(u.String())
+}
@@ -0,0 +1,106 @@
+package search
+
+import (
+	"net/url"
+	"slices"
+	"testing"
+)
+
+func TestMergeScraperPagination(t *testing.T) {
+	c, err := NewScraper(ScraperConfig{
+		Name:       "mojeek-patched",
+		Extends:    "mojeek",
+		Selectors:  ScraperSelectors{Snippet: "p.snippet"},
+		Pagination: ScraperPagination{Next: ".pagination a.next"},
+	}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := BuiltinScrapers["mojeek"]
+	want := ScraperPagination{Type: "offset", Start: 1, Step: 10, Next: ".pagination a.next"}
+	if c.cfg.Pagination != want {
+		t.Errorf("pagination = %+v, want %+v", c.cfg.Pagination, want)
+	}
+	if c.cfg.Selectors.Snippet != "p.snippet" || c.cfg.Selectors.Result != base.Selectors.Result {
+		t.Errorf("selectors = %+v", c.cfg.Selectors)
+	}
+	if c.cfg.URL != base.URL || c.cfg.Params["s"] != "{offset}" {
+		t.Errorf("request not inherited: %s %v", c.cfg.URL, c.cfg.Params)
+	}
+}
+
+func TestNewScraperExtends(t *testing.T) {
+	for _, base := range []string{"duckduckgo", "brave", "mojeek"} {
+		s, err := NewScraper(ScraperConfig{Name: base + "-patched", Extends: base}, Options{})
+		if err != nil {
+			t.Errorf("extending %s: %v", base, err)
+			continue
+		}
+		if s.Name() != base+"-patched" || s.cfg.URL != BuiltinScrapers[base].URL {
+			t.Errorf("extending %s: %s %s", base, s.Name(), s.cfg.URL)
+		}
+	}
+	if _, err := NewScraper(ScraperConfig{Name: "x", Extends: "nope"}, Options{}); err == nil {
+		t.Error("unknown base accepted")
+	}
+}
+
+// builtinScraperWant is what both the DuckDuckGo and the Brave fixture hold.
+var builtinScraperWant = []Result{
+	{Title: "Learn Go Fast - Online Course", URL: "https://ads.example.com/landing", Snippet: "Sponsored course on Go generics.", Kind: KindAd},
+	{
+		Title:   "Tutorial: Getting started with generics - The Go Programming Language",
+		URL:     "https://go.dev/doc/tutorial/generics",
+		Snippet: "This tutorial introduces the basics of generics in Go.",
+	},
+	{
+		Title:   "An Introduction To Generics - The Go Programming Language",
+		URL:     "https://go.dev/blog/intro-generics",
+		Snippet: "Go 1.18 adds support for generics.",
+	},
+}
+
+func TestDuckDuckGoScraper(t *testing.T) {
+	s, err := NewScraper(BuiltinScrapers["duckduckgo"], Options{Region: "jp"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := s.params("golang", 1); p.Get("q") != "golang" || p.Get("kl") != "jp-jp" {
+		t.Errorf("params = %v", p)
+	}
+
+	base, _ := url.Parse(ddgEndpoint)
+	page := s.parse(fixtureDoc(t, "duckduckgo.html"), base, 1)
+	if !slices.EqualFunc(page.Results, builtinScraperWant, resultEqual) {
+		t.Errorf("results = %+v\nwant %+v", page.Results, builtinScraperWant)
+	}
+	if !page.HasMore || page.NextParams.Get("s") != "10" || page.NextParams.Get("vqd") != "4-123" {
+		t.Errorf("has more %v, next params %v", page.HasMore, page.NextParams)
+	}
+}
+
+func TestBraveScraper(t *testing.T) {
+	s, err := NewScraper(BuiltinScrapers["brave"], Options{Region: "jp"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := s.params("golang", 2); p.Get("q") != "golang" || p.Get("country") != "jp" || p.Get("offset") != "1" {
+		t.Errorf("params = %v", p)
+	}
+
+	base, _ := url.Parse(braveEndpoint)
+	page := s.parse(fixtureDoc(t, "brave.html"), base, 2)
+	if !slices.EqualFunc(page.Results, builtinScraperWant, resultEqual) {
+		t.Errorf("results = %+v\nwant %+v", page.Results, builtinScraperWant)
+	}
+	if !page.HasMore {
+		t.Error("next link ignored")
+	}
+
+	// The link back to the previous page is not a next page
+	last := docFromString(t, `<div class="snippet" data-type="web"><a href="https://go.dev/"><div class="search-snippet-title">Go</div></a></div>
+		<div id="pagination"><a href="/search?q=go&amp;offset=1" class="button">Previous</a></div>`)
+	if page := s.parse(last, base, 3); len(page.Results) != 1 || page.HasMore {
+		t.Errorf("last page: %d results, has more %v", len(page.Results), page.HasMore)
+	}
+}
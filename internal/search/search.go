@@ -1,18 +1,74 @@
 package search
 
-import "net/url"
+import (
+	"context"
+	"net/url"
+	"slices"
+)
+
+// Kind distinguishes organic results from sponsored ones.
+type Kind int
+
+const (
+	KindOrganic Kind = iota
+	KindAd
+)
 
 type Result struct {
-	Title   string
-	URL     string
-	Snippet string
+	Title   string  `json:"title"`
+	URL     string  `json:"url"`
+	Snippet string  `json:"snippet,omitempty"`
+	Kind    Kind    `json:"kind,omitempty"`
+	Meta    []Field `json:"meta,omitempty"` // site-specific details, e.g. stars or votes
+}
+
+func (r Result) IsAd() bool { return r.Kind == KindAd }
+
+// Field is a labelled fact, e.g. "Designed by: Robert Griesemer".
+type Field struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Answer is an instant answer or knowledge panel returned alongside results.
+type Answer struct {
+	Title   string  `json:"title"`
+	Summary string  `json:"summary,omitempty"`
+	URL     string  `json:"url,omitempty"` // source of the answer
+	Facts   []Field `json:"facts,omitempty"`
+}
+
+// Correction is a spelling fix suggested, or already applied, by the engine.
+type Correction struct {
+	Query    string `json:"query"`    // corrected query
+	Original string `json:"original"` // query to run to search for the original spelling; "" when the engine offers none
+	Applied  bool   `json:"applied"`  // results are for Query rather than what was typed
 }
 
 type Page struct {
-	Results    []Result
-	NextParams url.Values
-	PageNum    int
-	HasMore    bool
+	Results    []Result    `json:"results"`
+	Answer     *Answer     `json:"answer,omitempty"`     // nil when the engine returned none
+	Correction *Correction `json:"correction,omitempty"` // nil when the query was not corrected
+	Related    []string    `json:"related,omitempty"`    // related search suggestions
+	NextParams url.Values  `json:"next_params,omitempty"`
+	PageNum    int         `json:"page"`
+	HasMore    bool        `json:"has_more"`
+	Literal    bool        `json:"literal,omitempty"` // spelling correction was turned off
+}
+
+// LiteralSearcher is implemented by backends that can search a query with
+// the engine's automatic spelling correction turned off.
+type LiteralSearcher interface {
+	SearchLiteral(query string) (*Page, error)
+	// PrevPageLiteral is PrevPage with spelling correction turned off.
+	PrevPageLiteral(query string, pageNum int) (*Page, error)
+}
+
+// ContextPager is implemented by backends whose PrevPage has to fetch the
+// pages before the one asked for. PrevPageContext stops doing so once ctx
+// is done.
+type ContextPager interface {
+	PrevPageContext(ctx context.Context, query string, pageNum int) (*Page, error)
 }
 
 type Backend interface {
@@ -21,3 +77,31 @@ type Backend interface {
 	PrevPage(query string, pageNum int) (*Page, error)
 	Name() string
 }
+
+// replay reaches pageNum by paging forward from the first page, for engines
+// that cannot jump to a page. It returns the last page when there are fewer.
+func replay(ctx context.Context, b Backend, query string, pageNum int) (*Page, error) {
+	page, err := b.Search(query)
+	if err != nil {
+		return nil, err
+	}
+	for page.PageNum < pageNum && page.HasMore {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if page, err = b.NextPage(page, query); err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+func appendUnique(list []string, s string) []string {
+	if s == "" {
+		return list
+	}
+	if slices.Contains(list, s) {
+		return list
+	}
+	return append(list, s)
+}
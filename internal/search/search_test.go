@@ -0,0 +1,69 @@
+package search
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// fixtureDoc parses testdata/name as HTML.
+func fixtureDoc(t *testing.T, name string) *goquery.Document {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	doc, err := goquery.NewDocumentFromReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+// serveFixture starts a server that answers every request with
+// testdata/name. *got is the URL of the last request.
+func serveFixture(t *testing.T, name string) (srv *httptest.Server, got **url.URL) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var last *url.URL
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		last = r.URL
+		w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &last
+}
+
+// docFromString parses an HTML snippet.
+func docFromString(t *testing.T, html string) *goquery.Document {
+	t.Helper()
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+// resultEqual compares results field by field.
+func resultEqual(a, b Result) bool {
+	return a.Title == b.Title && a.URL == b.URL && a.Snippet == b.Snippet &&
+		a.Kind == b.Kind && slices.Equal(a.Meta, b.Meta)
+}
+
+// dateOf formats a Unix time as the API backends show dates, in the local
+// time zone.
+func dateOf(sec int64) string {
+	return time.Unix(sec, 0).Format("2006-01-02")
+}
@@ -0,0 +1,137 @@
+package search
+
+import (
+	"cmp"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+func init() {
+	Register("stackoverflow", []string{"so", "stackexchange"}, func(o Options) (Backend, error) {
+		return &StackExchange{Site: "stackoverflow"}, nil
+	})
+}
+
+// StackExchange searches questions and answers on a Stack Exchange site
+// through the public API.
+type StackExchange struct {
+	Site     string // API site parameter, e.g. "stackoverflow", "superuser", "unix"
+	Endpoint string // overrides the search API URL
+}
+
+const (
+	stackExchangeEndpoint = "https://api.stackexchange.com/2.3/search/excerpts"
+	stackExchangePageSize = 10
+)
+
+func (s *StackExchange) Name() string {
+	if s.Site == "" || s.Site == "stackoverflow" {
+		return "stackoverflow"
+	}
+	return "stackexchange:" + s.Site
+}
+
+func (s *StackExchange) Search(query string) (*Page, error) {
+	return s.fetch(query, 1)
+}
+
+func (s *StackExchange) NextPage(prev *Page, query string) (*Page, error) {
+	if !prev.HasMore {
+		return nil, fmt.Errorf("no more pages")
+	}
+	return s.fetch(query, prev.PageNum+1)
+}
+
+func (s *StackExchange) PrevPage(query string, pageNum int) (*Page, error) {
+	return s.fetch(query, max(1, pageNum))
+}
+
+func (s *StackExchange) site() string {
+	if s.Site == "" {
+		return "stackoverflow"
+	}
+	return s.Site
+}
+
+func (s *StackExchange) fetch(query string, pageNum int) (*Page, error) {
+	params := url.Values{
+		"q":        {query},
+		"site":     {s.site()},
+		"order":    {"desc"},
+		"sort":     {"relevance"},
+		"page":     {fmt.Sprint(pageNum)},
+		"pagesize": {fmt.Sprint(stackExchangePageSize)},
+	}
+
+	var resp struct {
+		HasMore bool `json:"has_more"`
+		Items   []struct {
+			ItemType          string   `json:"item_type"`
+			QuestionID        int      `json:"question_id"`
+			AnswerID          int      `json:"answer_id"`
+			Title             string   `json:"title"`
+			Excerpt           string   `json:"excerpt"`
+			Score             int      `json:"score"`
+			AnswerCount       int      `json:"answer_count"`
+			IsAccepted        bool     `json:"is_accepted"`
+			HasAcceptedAnswer bool     `json:"has_accepted_answer"`
+			Tags              []string `json:"tags"`
+			CreationDate      int64    `json:"creation_date"`
+		} `json:"items"`
+		ErrorMessage string `json:"error_message"`
+	}
+	if err := getJSON(cmp.Or(s.Endpoint, stackExchangeEndpoint), params, nil, &resp); err != nil {
+		return nil, err
+	}
+	if resp.ErrorMessage != "" {
+		return nil, fmt.Errorf("stack exchange: %s", resp.ErrorMessage)
+	}
+
+	host := stackExchangeHost(s.site())
+	page := &Page{PageNum: pageNum, HasMore: resp.HasMore}
+	for _, it := range resp.Items {
+		r := Result{
+			Title:   htmlText(it.Title),
+			URL:     fmt.Sprintf("https://%s/q/%d", host, it.QuestionID),
+			Snippet: htmlText(it.Excerpt),
+			Meta:    []Field{{Name: "Votes", Value: fmt.Sprint(it.Score)}},
+		}
+		if it.ItemType == "answer" {
+			r.Title = "A: " + r.Title
+			r.URL = fmt.Sprintf("https://%s/a/%d", host, it.AnswerID)
+			if it.IsAccepted {
+				r.Meta = append(r.Meta, Field{Name: "Accepted", Value: "yes"})
+			}
+		} else {
+			answers := fmt.Sprint(it.AnswerCount)
+			if it.HasAcceptedAnswer {
+				answers += " (accepted)"
+			}
+			r.Meta = append(r.Meta, Field{Name: "Answers", Value: answers})
+		}
+		if len(it.Tags) > 0 {
+			r.Meta = append(r.Meta, Field{Name: "Tags", Value: strings.Join(it.Tags, ", ")})
+		}
+		if it.CreationDate > 0 {
+			r.Meta = append(r.Meta, Field{Name: "Date", Value: time.Unix(it.CreationDate, 0).Format("2006-01-02")})
+		}
+		page.Results = append(page.Results, r)
+	}
+	return page, nil
+}
+
+// stackExchangeHost maps an API site name to its web host.
+func stackExchangeHost(site string) string {
+	switch site {
+	case "stackoverflow", "superuser", "serverfault", "askubuntu", "stackapps":
+		return site + ".com"
+	case "mathoverflow":
+		return "mathoverflow.net"
+	}
+	if strings.Contains(site, ".") {
+		return site
+	}
+	return site + ".stackexchange.com"
+}
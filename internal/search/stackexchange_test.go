@@ -0,0 +1,54 @@
+package search
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestStackExchange(t *testing.T) {
+	srv, got := serveFixture(t, "stackexchange.json")
+	s := &StackExchange{Endpoint: srv.URL}
+	page, err := s.Search("string to int")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q := (*got).Query(); q.Get("site") != "stackoverflow" || q.Get("q") != "string to int" || q.Get("page") != "1" {
+		t.Errorf("query = %v", q)
+	}
+
+	want := []Result{
+		{
+			Title:   "How do I convert a string to an int in Go?",
+			URL:     "https://stackoverflow.com/q/11462879",
+			Snippet: `I want to convert a string to an "int"`,
+			Meta: []Field{{"Votes", "872"}, {"Answers", "6 (accepted)"},
+				{"Tags", "go, type-conversion"}, {"Date", dateOf(1342087980)}},
+		},
+		{
+			Title:   "A: How do I convert a string to an int in Go?",
+			URL:     "https://stackoverflow.com/a/11463164",
+			Snippet: "Use strconv.Atoi",
+			Meta:    []Field{{"Votes", "1204"}, {"Accepted", "yes"}, {"Date", dateOf(1342089000)}},
+		},
+	}
+	if !slices.EqualFunc(page.Results, want, resultEqual) {
+		t.Errorf("results = %+v\nwant %+v", page.Results, want)
+	}
+	if !page.HasMore {
+		t.Error("has_more ignored")
+	}
+}
+
+func TestStackExchangeHost(t *testing.T) {
+	for site, want := range map[string]string{
+		"stackoverflow":        "stackoverflow.com",
+		"superuser":            "superuser.com",
+		"mathoverflow":         "mathoverflow.net",
+		"unix":                 "unix.stackexchange.com",
+		"ja.stackoverflow.com": "ja.stackoverflow.com",
+	} {
+		if got := stackExchangeHost(site); got != want {
+			t.Errorf("stackExchangeHost(%q) = %q, want %q", site, got, want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package search
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Suggester is implemented by backends that offer query completions.
+type Suggester interface {
+	Suggest(ctx context.Context, query string) ([]string, error)
+}
+
+// suggestEndpoints are the completion endpoints, by engine.
+var suggestEndpoints = map[string]string{
+	"duckduckgo": "https://duckduckgo.com/ac/",
+	"brave":      "https://search.brave.com/api/suggest",
+}
+
+// SetSuggestEndpoint makes engine fetch completions from endpoint, e.g. a
+// local stub server, instead of the engine's own.
+func SetSuggestEndpoint(engine, endpoint string) error {
+	if _, ok := suggestEndpoints[engine]; !ok {
+		names := slices.Sorted(maps.Keys(suggestEndpoints))
+		return fmt.Errorf("%s has no completions (use %s)", engine, strings.Join(names, ", "))
+	}
+	if u, err := url.Parse(endpoint); err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("invalid suggest endpoint %q for %s", endpoint, engine)
+	}
+	suggestEndpoints[engine] = endpoint
+	return nil
+}
+
+func (d *DuckDuckGo) Suggest(ctx context.Context, query string) ([]string, error) {
+	params := url.Values{"q": {query}, "type": {"list"}}
+	if kl := ddgRegion(d.Region, d.Language); kl != "" {
+		params.Set("kl", kl)
+	}
+	return fetchSuggestions(ctx, ddgClient, cmp.Or(d.SuggestEndpoint, suggestEndpoints["duckduckgo"]), params, d.headers())
+}
+
+func (b *Brave) Suggest(ctx context.Context, query string) ([]string, error) {
+	params := url.Values{"q": {query}}
+	if c := braveRegion(b.Region); c != "" {
+		params.Set("country", c)
+	}
+	if l := braveLanguage(b.Language); l != "" {
+		params.Set("search_lang", l)
+	}
+	return fetchSuggestions(ctx, braveClient, cmp.Or(b.SuggestEndpoint, suggestEndpoints["brave"]), params, b.headers())
+}
+
+// fetchSuggestions queries an OpenSearch suggestions endpoint, which answers
+// with ["query", ["completion", ...]].
+func fetchSuggestions(ctx context.Context, client *http.Client, endpoint string, params url.Values, headers http.Header) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header = headers.Clone()
+	req.Header.Del("Content-Type")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching suggestions: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("suggest returned status %d", resp.StatusCode)
+	}
+
+	var raw []json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("parsing suggestions: %w", err)
+	}
+	if len(raw) < 2 {
+		return nil, nil
+	}
+	var list []string
+	if err := json.Unmarshal(raw[1], &list); err != nil {
+		return nil, fmt.Errorf("parsing suggestions: %w", err)
+	}
+	return list, nil
+}
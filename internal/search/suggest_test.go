@@ -0,0 +1,119 @@
+package search
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+)
+
+// suggestStub answers like an OpenSearch suggestions endpoint and records
+// the last request.
+func suggestStub(t *testing.T, status int, body string) (*httptest.Server, *url.Values) {
+	t.Helper()
+	var got url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.Query()
+		if r.Header.Get("Accept") != "application/json" {
+			t.Errorf("Accept = %q, want application/json", r.Header.Get("Accept"))
+		}
+		if r.Header.Get("Content-Type") != "" {
+			t.Errorf("Content-Type %q sent on a GET", r.Header.Get("Content-Type"))
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &got
+}
+
+func TestFetchSuggestions(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    []string
+		wantErr bool
+	}{
+		{"list", http.StatusOK, `["gola", ["golang", "golang generics"]]`, []string{"golang", "golang generics"}, false},
+		{"extra fields", http.StatusOK, `["gola", ["golang"], [], {"google:suggesttype": []}]`, []string{"golang"}, false},
+		{"query only", http.StatusOK, `["gola"]`, nil, false},
+		{"bad status", http.StatusTooManyRequests, ``, nil, true},
+		{"not json", http.StatusOK, `<html>`, nil, true},
+		{"not a list", http.StatusOK, `["gola", "golang"]`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, got := suggestStub(t, tt.status, tt.body)
+			list, err := fetchSuggestions(context.Background(), srv.Client(), srv.URL, url.Values{"q": {"gola"}}, http.Header{"Content-Type": {"text/plain"}})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if !slices.Equal(list, tt.want) {
+				t.Errorf("suggestions = %q, want %q", list, tt.want)
+			}
+			if got.Get("q") != "gola" {
+				t.Errorf("q = %q", got.Get("q"))
+			}
+		})
+	}
+}
+
+func TestFetchSuggestionsCanceled(t *testing.T) {
+	srv, _ := suggestStub(t, http.StatusOK, `["q", []]`)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := fetchSuggestions(ctx, srv.Client(), srv.URL, nil, http.Header{}); err == nil {
+		t.Error("canceled request succeeded")
+	}
+}
+
+func TestDuckDuckGoSuggest(t *testing.T) {
+	srv, got := suggestStub(t, http.StatusOK, `["gola", ["golang"]]`)
+	d := &DuckDuckGo{Region: "jp", SuggestEndpoint: srv.URL}
+	list, err := d.Suggest(context.Background(), "gola")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(list, []string{"golang"}) {
+		t.Errorf("suggestions = %q", list)
+	}
+	if got.Get("type") != "list" || got.Get("kl") != "jp-jp" {
+		t.Errorf("params = %v, want type=list and kl=jp-jp", *got)
+	}
+}
+
+func TestBraveSuggest(t *testing.T) {
+	srv, got := suggestStub(t, http.StatusOK, `["gola", ["golang", "golang tour"]]`)
+	b := &Brave{Region: "de", SuggestEndpoint: srv.URL}
+	list, err := b.Suggest(context.Background(), "gola")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(list, []string{"golang", "golang tour"}) {
+		t.Errorf("suggestions = %q", list)
+	}
+	if got.Get("country") != "de" {
+		t.Errorf("country = %q, want de", got.Get("country"))
+	}
+}
+
+func TestSetSuggestEndpoint(t *testing.T) {
+	saved := suggestEndpoints["brave"]
+	t.Cleanup(func() { suggestEndpoints["brave"] = saved })
+
+	if err := SetSuggestEndpoint("brave", "http://127.0.0.1:8080/ac"); err != nil {
+		t.Fatal(err)
+	}
+	if got := suggestEndpoints["brave"]; got != "http://127.0.0.1:8080/ac" {
+		t.Errorf("endpoint = %q", got)
+	}
+	if err := SetSuggestEndpoint("wikipedia", "http://127.0.0.1/"); err == nil {
+		t.Error("engine without completions accepted")
+	}
+	if err := SetSuggestEndpoint("brave", "localhost"); err == nil {
+		t.Error("endpoint without scheme accepted")
+	}
+}
@@ -0,0 +1,100 @@
+package search
+
+import (
+	"cmp"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+func init() {
+	Register("wikipedia", []string{"wiki", "w"}, func(o Options) (Backend, error) {
+		return &Wikipedia{Region: o.Region, Lang: primaryLanguage(o.Language)}, nil
+	})
+}
+
+// Wikipedia searches article text through the MediaWiki search API.
+type Wikipedia struct {
+	Region string // selects the region's language edition, e.g. "jp" -> ja.wikipedia.org
+	Lang   string // overrides Region, e.g. "en", "ja"
+	// Endpoint overrides the MediaWiki API URL of the language edition.
+	// Result links still point at the edition's site.
+	Endpoint string
+}
+
+const wikipediaPageSize = 10
+
+func (w *Wikipedia) Name() string { return "wikipedia" }
+
+func (w *Wikipedia) Search(query string) (*Page, error) {
+	return w.fetch(query, 1)
+}
+
+func (w *Wikipedia) NextPage(prev *Page, query string) (*Page, error) {
+	if !prev.HasMore {
+		return nil, fmt.Errorf("no more pages")
+	}
+	return w.fetch(query, prev.PageNum+1)
+}
+
+func (w *Wikipedia) PrevPage(query string, pageNum int) (*Page, error) {
+	return w.fetch(query, max(1, pageNum))
+}
+
+func (w *Wikipedia) lang() string {
+	if w.Lang != "" {
+		return w.Lang
+	}
+	if r, err := LookupRegion(w.Region); err == nil {
+		if l := primaryLanguage(r.Language); l != "nb" {
+			return l
+		}
+		return "no" // Norwegian Bokmål is no.wikipedia.org
+	}
+	return "en"
+}
+
+func (w *Wikipedia) fetch(query string, pageNum int) (*Page, error) {
+	lang := w.lang()
+	params := url.Values{
+		"action":   {"query"},
+		"list":     {"search"},
+		"format":   {"json"},
+		"srsearch": {query},
+		"srlimit":  {fmt.Sprint(wikipediaPageSize)},
+		"sroffset": {fmt.Sprint((pageNum - 1) * wikipediaPageSize)},
+		"srprop":   {"snippet|wordcount|timestamp"},
+	}
+
+	var resp struct {
+		Continue *struct {
+			SROffset int `json:"sroffset"`
+		} `json:"continue"`
+		Query struct {
+			Search []struct {
+				Title     string `json:"title"`
+				Snippet   string `json:"snippet"`
+				WordCount int    `json:"wordcount"`
+				Timestamp string `json:"timestamp"`
+			} `json:"search"`
+		} `json:"query"`
+	}
+	endpoint := cmp.Or(w.Endpoint, "https://"+lang+".wikipedia.org/w/api.php")
+	if err := getJSON(endpoint, params, nil, &resp); err != nil {
+		return nil, err
+	}
+
+	page := &Page{PageNum: pageNum, HasMore: resp.Continue != nil}
+	for _, s := range resp.Query.Search {
+		page.Results = append(page.Results, Result{
+			Title:   s.Title,
+			URL:     "https://" + lang + ".wikipedia.org/wiki/" + url.PathEscape(strings.ReplaceAll(s.Title, " ", "_")),
+			Snippet: htmlText(s.Snippet),
+			Meta: []Field{
+				{Name: "Words", Value: humanCount(s.WordCount)},
+				{Name: "Edited", Value: shortDate(s.Timestamp)},
+			},
+		})
+	}
+	return page, nil
+}
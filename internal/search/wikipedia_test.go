@@ -0,0 +1,57 @@
+package search
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestWikipedia(t *testing.T) {
+	srv, got := serveFixture(t, "wikipedia.json")
+	w := &Wikipedia{Region: "jp", Endpoint: srv.URL}
+	page, err := w.fetch("go", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q := (*got).Query(); q.Get("srsearch") != "go" || q.Get("sroffset") != "20" {
+		t.Errorf("query = %v", q)
+	}
+
+	// The region picks the edition the results link to
+	want := []Result{
+		{
+			Title:   "Go (programming language)",
+			URL:     "https://ja.wikipedia.org/wiki/Go_%28programming_language%29",
+			Snippet: "Go is a statically typed, compiled high-level programming language",
+			Meta:    []Field{{"Words", "6.4k"}, {"Edited", "2025-03-02"}},
+		},
+		{
+			Title:   "Go (game)",
+			URL:     "https://ja.wikipedia.org/wiki/Go_%28game%29",
+			Snippet: "Go is an abstract strategy board game & the oldest still played",
+			Meta:    []Field{{"Words", "15.2k"}, {"Edited", "2025-01-15"}},
+		},
+	}
+	if !slices.EqualFunc(page.Results, want, resultEqual) {
+		t.Errorf("results = %+v\nwant %+v", page.Results, want)
+	}
+	if page.PageNum != 3 || !page.HasMore {
+		t.Errorf("page %d, has more %v", page.PageNum, page.HasMore)
+	}
+}
+
+func TestWikipediaLang(t *testing.T) {
+	tests := []struct {
+		w    Wikipedia
+		want string
+	}{
+		{Wikipedia{}, "en"},
+		{Wikipedia{Region: "de"}, "de"},
+		{Wikipedia{Region: "no"}, "no"},
+		{Wikipedia{Region: "jp", Lang: "en"}, "en"},
+	}
+	for _, tt := range tests {
+		if got := tt.w.lang(); got != tt.want {
+			t.Errorf("%+v.lang() = %q, want %q", tt.w, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,358 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/frort/ksk/internal/search"
+)
+
+// maxPerEngine bounds concurrent upstream requests per engine so a burst of
+// API clients does not trip the engines' rate limits.
+const maxPerEngine = 4
+
+// maxPage is the deepest page /search serves. Engines without page
+// parameters fetch every page before it.
+const maxPage = 50
+
+// Server exposes the search backends over HTTP.
+//
+// Backends are created per request, so per-request state never leaks between
+// clients. The engines' HTTP clients (and their cookie jars) are shared
+// package-level values; net/http clients and cookiejar are safe for
+// concurrent use, and the cookies only hold the engines' anti-bot state for
+// ksk itself, not anything belonging to API callers.
+type Server struct {
+	Engine     string // default engine
+	Region     string // default region
+	Language   string // default BCP 47 language tag
+	SafeSearch search.SafeSearch
+	// Engines are the engines clients may use, by canonical name. Empty
+	// allows every registered engine.
+	Engines   []string
+	Sanitizer *search.Sanitizer
+	// Timeout bounds each upstream search.
+	Timeout time.Duration
+
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+}
+
+// Result is the JSON form of search.Result.
+type Result struct {
+	Title   string         `json:"title"`
+	URL     string         `json:"url"`
+	Snippet string         `json:"snippet,omitempty"`
+	Ad      bool           `json:"ad,omitempty"`
+	Meta    []search.Field `json:"meta,omitempty"`
+}
+
+// Response is the body of /search.
+type Response struct {
+	Query      string             `json:"query"`
+	Engine     string             `json:"engine"`
+	Region     string             `json:"region,omitempty"`
+	Language   string             `json:"lang,omitempty"`
+	Page       int                `json:"page"`
+	HasMore    bool               `json:"has_more"`
+	Results    []Result           `json:"results"`
+	Answer     *search.Answer     `json:"answer,omitempty"`
+	Correction *search.Correction `json:"correction,omitempty"`
+	Related    []string           `json:"related,omitempty"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Handler returns the HTTP routes.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.handleIndex)
+	mux.HandleFunc("GET /search", s.handleSearch)
+	mux.HandleFunc("GET /suggest", s.handleSuggest)
+	mux.HandleFunc("GET /engines", s.handleEngines)
+	mux.HandleFunc("GET /opensearch.xml", s.handleOpenSearch)
+	return mux
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	query := strings.TrimSpace(q.Get("q"))
+	if query == "" {
+		writeError(w, http.StatusBadRequest, "missing q")
+		return
+	}
+	pageNum := 1
+	if p := q.Get("page"); p != "" {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, "invalid page")
+			return
+		}
+		if n > maxPage {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("page must be at most %d", maxPage))
+			return
+		}
+		pageNum = n
+	}
+	region := cmp.Or(q.Get("region"), s.Region)
+	language := cmp.Or(q.Get("lang"), s.Language)
+	backend, err := s.newBackend(q.Get("engine"), region, language)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := s.run(r.Context(), backend, func(ctx context.Context) (*search.Page, error) {
+		if pageNum == 1 {
+			return backend.Search(query)
+		}
+		if cp, ok := backend.(search.ContextPager); ok {
+			return cp.PrevPageContext(ctx, query, pageNum)
+		}
+		return backend.PrevPage(query, pageNum)
+	})
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	resp := s.response(query, backend.Name(), region, language, page, q.Get("ads") == "1")
+	if q.Get("format") == "html" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = resultsTmpl.Execute(w, resp)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// newBackend creates engine, or the default one when engine is empty, if
+// clients may use it.
+func (s *Server) newBackend(engine, region, language string) (search.Backend, error) {
+	name, _, err := search.EngineRegions(cmp.Or(engine, s.Engine))
+	if err != nil {
+		return nil, err
+	}
+	if !s.allowed(name) {
+		return nil, fmt.Errorf("engine %s is not served (use %s)", name, strings.Join(s.engines(), ", "))
+	}
+	return search.New(name, search.Options{Region: region, Language: language, SafeSearch: s.SafeSearch})
+}
+
+func (s *Server) allowed(name string) bool {
+	return len(s.Engines) == 0 || slices.Contains(s.Engines, name)
+}
+
+// engines returns the names of the engines clients may use, sorted.
+func (s *Server) engines() []string {
+	if len(s.Engines) == 0 {
+		return search.Engines()
+	}
+	return slices.Sorted(slices.Values(s.Engines))
+}
+
+// run calls fn while holding one of the engine's upstream slots, giving up
+// when the client goes away or the timeout elapses. fn is passed a context
+// that is done by then.
+func (s *Server) run(ctx context.Context, backend search.Backend, fn func(context.Context) (*search.Page, error)) (*search.Page, error) {
+	slots := s.engineSlots(backend.Name())
+	timeout := cmp.Or(s.Timeout, 30*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	select {
+	case slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("engine busy: %w", ctx.Err())
+	}
+
+	type result struct {
+		page *search.Page
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer func() { <-slots }()
+		page, err := fn(ctx)
+		done <- result{page, err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.page, res.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *Server) engineSlots(name string) chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.slots == nil {
+		s.slots = make(map[string]chan struct{})
+	}
+	c, ok := s.slots[name]
+	if !ok {
+		c = make(chan struct{}, maxPerEngine)
+		s.slots[name] = c
+	}
+	return c
+}
+
+func (s *Server) response(query, engine, region, language string, page *search.Page, showAds bool) Response {
+	resp := Response{
+		Query:      query,
+		Engine:     engine,
+		Region:     region,
+		Language:   language,
+		Page:       page.PageNum,
+		HasMore:    page.HasMore && page.PageNum < maxPage,
+		Results:    []Result{},
+		Answer:     page.Answer,
+		Correction: page.Correction,
+		Related:    page.Related,
+	}
+	for _, r := range page.Results {
+		if r.IsAd() && !showAds {
+			continue
+		}
+		resp.Results = append(resp.Results, Result{
+			Title:   r.Title,
+			URL:     s.clean(r.URL),
+			Snippet: r.Snippet,
+			Ad:      r.IsAd(),
+			Meta:    r.Meta,
+		})
+	}
+	return resp
+}
+
+func (s *Server) clean(u string) string {
+	if s.Sanitizer == nil {
+		return u
+	}
+	return s.Sanitizer.Clean(u)
+}
+
+// handleSuggest answers in the OpenSearch suggestions format browsers use.
+func (s *Server) handleSuggest(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	query := q.Get("q")
+	suggestions := []string{}
+
+	backend, err := s.newBackend(q.Get("engine"), cmp.Or(q.Get("region"), s.Region), cmp.Or(q.Get("lang"), s.Language))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if sg, ok := backend.(search.Suggester); ok && strings.TrimSpace(query) != "" {
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+		if list, err := sg.Suggest(ctx, query); err == nil {
+			suggestions = list
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/x-suggestions+json")
+	_ = json.NewEncoder(w).Encode([]any{query, suggestions})
+}
+
+func (s *Server) handleEngines(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"default": s.Engine,
+		"engines": s.engines(),
+	})
+}
+
+func (s *Server) handleOpenSearch(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/opensearchdescription+xml")
+	_ = openSearchTmpl.Execute(w, map[string]string{
+		"Base":   baseURL(r),
+		"Engine": s.Engine,
+	})
+}
+
+func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_ = resultsTmpl.Execute(w, Response{Engine: s.Engine, Region: s.Region, Language: s.Language})
+}
+
+// baseURL reconstructs the externally visible origin of the server.
+func baseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, errorResponse{Error: msg})
+}
+
+var openSearchTmpl = texttemplate.Must(texttemplate.New("opensearch").Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<OpenSearchDescription xmlns="http://a9.com/-/spec/opensearch/1.1/">
+  <ShortName>ksk</ShortName>
+  <Description>ksk web search ({{html .Engine}})</Description>
+  <InputEncoding>UTF-8</InputEncoding>
+  <Url type="text/html" method="get" template="{{html .Base}}/search?q={searchTerms}&amp;format=html"/>
+  <Url type="application/json" method="get" template="{{html .Base}}/search?q={searchTerms}&amp;page={startPage?}"/>
+  <Url type="application/x-suggestions+json" method="get" template="{{html .Base}}/suggest?q={searchTerms}"/>
+</OpenSearchDescription>
+`))
+
+var resultsTmpl = template.Must(template.New("results").Funcs(template.FuncMap{
+	"add": func(a, b int) int { return a + b },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{if .Query}}{{.Query}} - {{end}}ksk</title>
+<link rel="search" type="application/opensearchdescription+xml" title="ksk" href="/opensearch.xml">
+<style>
+body { font-family: sans-serif; max-width: 50em; margin: 1em auto; padding: 0 1em; }
+.r { margin: 1.2em 0; } .u { color: #5f8f5f; font-size: .9em; } .s { color: #555; } .m { color: #a07030; font-size: .85em; }
+.ad { opacity: .6; } .a { border: 1px solid #5faf5f; padding: .5em 1em; }
+</style>
+</head>
+<body>
+<form action="/search">
+<input type="hidden" name="format" value="html">
+<input type="hidden" name="engine" value="{{.Engine}}">
+{{with .Region}}<input type="hidden" name="region" value="{{.}}">{{end}}
+{{with .Language}}<input type="hidden" name="lang" value="{{.}}">{{end}}
+<input name="q" value="{{.Query}}" size="50" autofocus> <button>Search</button>
+</form>
+{{with .Correction}}<p>{{if .Applied}}Showing results for <b>{{.Query}}</b>{{else}}Did you mean <b>{{.Query}}</b>?{{end}}</p>{{end}}
+{{with .Answer}}<div class="a"><b>{{.Title}}</b><p>{{.Summary}}</p>{{range .Facts}}<div>{{.Name}}: {{.Value}}</div>{{end}}{{if .URL}}<a href="{{.URL}}">{{.URL}}</a>{{end}}</div>{{end}}
+{{range .Results}}<div class="r{{if .Ad}} ad{{end}}">
+<a href="{{.URL}}">{{if .Ad}}[Ad] {{end}}{{.Title}}</a>
+<div class="u">{{.URL}}</div>
+{{if .Meta}}<div class="m">{{range $i, $f := .Meta}}{{if $i}} · {{end}}{{$f.Name}}: {{$f.Value}}{{end}}</div>{{end}}
+<div class="s">{{.Snippet}}</div>
+</div>{{end}}
+{{if .Query}}<p>
+{{if gt .Page 1}}<a href="/search?format=html&amp;engine={{.Engine}}{{with .Region}}&amp;region={{.}}{{end}}{{with .Language}}&amp;lang={{.}}{{end}}&amp;q={{.Query}}&amp;page={{add .Page -1}}">&laquo; prev</a>{{end}}
+page {{.Page}}
+{{if .HasMore}}<a href="/search?format=html&amp;engine={{.Engine}}{{with .Region}}&amp;region={{.}}{{end}}{{with .Language}}&amp;lang={{.}}{{end}}&amp;q={{.Query}}&amp;page={{add .Page 1}}">next &raquo;</a>{{end}}
+</p>{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,284 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/frort/ksk/internal/search"
+)
+
+// fakeBackend serves a fixed page with one organic result and one ad. The
+// query "fail" fails upstream.
+type fakeBackend struct{}
+
+func (fakeBackend) Name() string { return "fake" }
+
+func (f fakeBackend) Search(query string) (*search.Page, error) {
+	return f.PrevPage(query, 1)
+}
+
+func (f fakeBackend) NextPage(prev *search.Page, query string) (*search.Page, error) {
+	return f.PrevPage(query, prev.PageNum+1)
+}
+
+func (fakeBackend) PrevPage(query string, pageNum int) (*search.Page, error) {
+	if query == "fail" {
+		return nil, errors.New("upstream down")
+	}
+	return &search.Page{
+		PageNum: pageNum,
+		HasMore: true,
+		Results: []search.Result{
+			{Title: "Go", URL: "https://go.dev/?utm_source=x", Snippet: "The Go language"},
+			{Title: "Buy Go", URL: "https://ads.example.com", Kind: search.KindAd},
+		},
+		Related: []string{query + " tutorial"},
+	}, nil
+}
+
+func (fakeBackend) Suggest(_ context.Context, query string) ([]string, error) {
+	return []string{query + "lang", query + " tour"}, nil
+}
+
+// replayBackend pages by replaying and records the context it was given.
+type replayBackend struct{ fakeBackend }
+
+var replayCtx context.Context
+
+func (replayBackend) Name() string { return "fake-replay" }
+
+func (r replayBackend) PrevPageContext(ctx context.Context, query string, pageNum int) (*search.Page, error) {
+	replayCtx = ctx
+	return r.PrevPage(query, pageNum)
+}
+
+// plainBackend has no suggestions: embedding the interface hides Suggest.
+type plainBackend struct{ search.Backend }
+
+func (plainBackend) Name() string { return "fake-plain" }
+
+// fakeOpts holds the options "fake" was last created with.
+var fakeOpts search.Options
+
+func init() {
+	search.Register("fake", nil, func(o search.Options) (search.Backend, error) {
+		fakeOpts = o
+		return fakeBackend{}, nil
+	})
+	search.Register("fake-replay", nil, func(search.Options) (search.Backend, error) { return replayBackend{}, nil })
+	search.Register("fake-plain", nil, func(search.Options) (search.Backend, error) { return plainBackend{fakeBackend{}}, nil })
+}
+
+func get(t *testing.T, s *Server, target string) *http.Response {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest("GET", target, nil))
+	return rec.Result()
+}
+
+func decode[T any](t *testing.T, resp *http.Response) T {
+	t.Helper()
+	var v T
+	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestSearchBadRequests(t *testing.T) {
+	s := &Server{Engine: "fake"}
+	for _, target := range []string{
+		"/search",
+		"/search?q=+",
+		"/search?q=go&page=abc",
+		"/search?q=go&page=0",
+		"/search?q=go&page=51",
+		"/search?q=go&engine=nope",
+	} {
+		resp := get(t, s, target)
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", target, resp.StatusCode)
+			continue
+		}
+		if e := decode[errorResponse](t, resp); e.Error == "" {
+			t.Errorf("%s: empty error message", target)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	s := &Server{Engine: "fake", Sanitizer: search.NewSanitizer()}
+	resp := get(t, s, "/search?q=go&page=2")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	got := decode[Response](t, resp)
+	if got.Query != "go" || got.Engine != "fake" || got.Page != 2 || !got.HasMore {
+		t.Errorf("response = %+v", got)
+	}
+	// Ads are left out and tracking parameters removed
+	if len(got.Results) != 1 || got.Results[0].URL != "https://go.dev/" || got.Results[0].Ad {
+		t.Errorf("results = %+v", got.Results)
+	}
+	if len(got.Related) != 1 || got.Related[0] != "go tutorial" {
+		t.Errorf("related = %q", got.Related)
+	}
+
+	got = decode[Response](t, get(t, s, "/search?q=go&ads=1"))
+	if len(got.Results) != 2 || !got.Results[1].Ad {
+		t.Errorf("results with ads = %+v", got.Results)
+	}
+}
+
+func TestSearchJSONShape(t *testing.T) {
+	s := &Server{Engine: "fake"}
+	body, _ := io.ReadAll(get(t, s, "/search?q=go").Body)
+	var raw map[string]any
+	if err := json.Unmarshal(body, &raw); err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{"query", "engine", "page", "has_more", "results", "related"} {
+		if _, ok := raw[k]; !ok {
+			t.Errorf("missing %q in %s", k, body)
+		}
+	}
+	r := raw["results"].([]any)[0].(map[string]any)
+	for _, k := range []string{"title", "url", "snippet"} {
+		if _, ok := r[k]; !ok {
+			t.Errorf("result missing %q: %v", k, r)
+		}
+	}
+	if _, ok := r["ad"]; ok {
+		t.Errorf("organic result carries ad: %v", r)
+	}
+}
+
+func TestSearchLastPage(t *testing.T) {
+	got := decode[Response](t, get(t, &Server{Engine: "fake"}, "/search?q=go&page=50"))
+	if got.HasMore {
+		t.Error("page 50 links to a page that is refused")
+	}
+}
+
+func TestSearchUpstreamError(t *testing.T) {
+	resp := get(t, &Server{Engine: "fake"}, "/search?q=fail")
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Errorf("status %d, want 502", resp.StatusCode)
+	}
+}
+
+func TestSearchReplayContext(t *testing.T) {
+	resp := get(t, &Server{Engine: "fake-replay"}, "/search?q=go&page=3")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d", resp.StatusCode)
+	}
+	if replayCtx == nil {
+		t.Fatal("PrevPageContext not used")
+	}
+	if _, ok := replayCtx.Deadline(); !ok {
+		t.Error("replay context has no deadline")
+	}
+	// Done once the request is answered
+	if replayCtx.Err() == nil {
+		t.Error("replay context still live after the response")
+	}
+}
+
+func TestSearchHTML(t *testing.T) {
+	resp := get(t, &Server{Engine: "fake"}, "/search?q=go&format=html")
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") || !strings.Contains(string(body), `href="https://go.dev/?utm_source=x"`) {
+		t.Errorf("HTML results: %s", body)
+	}
+}
+
+func TestSearchOptions(t *testing.T) {
+	s := &Server{Engine: "fake", Region: "jp", Language: "ja", SafeSearch: search.SafeStrict}
+	get(t, s, "/search?q=go")
+	if want := (search.Options{Region: "jp", Language: "ja", SafeSearch: search.SafeStrict}); fakeOpts != want {
+		t.Errorf("options = %+v, want %+v", fakeOpts, want)
+	}
+
+	// Page links keep the region and language of the request
+	body, _ := io.ReadAll(get(t, s, "/search?q=go&region=de&lang=de&page=2&format=html").Body)
+	for _, want := range []string{
+		`href="/search?format=html&amp;engine=fake&amp;region=de&amp;lang=de&amp;q=go&amp;page=1"`,
+		`href="/search?format=html&amp;engine=fake&amp;region=de&amp;lang=de&amp;q=go&amp;page=3"`,
+		`<input type="hidden" name="region" value="de">`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("missing %s in\n%s", want, body)
+		}
+	}
+}
+
+func TestServedEngines(t *testing.T) {
+	s := &Server{Engine: "fake", Engines: []string{"fake", "fake-plain"}}
+	for _, target := range []string{"/search?q=go&engine=fake-replay", "/suggest?q=go&engine=fake-replay"} {
+		if resp := get(t, s, target); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", target, resp.StatusCode)
+		}
+	}
+	if resp := get(t, s, "/search?q=go&engine=fake-plain"); resp.StatusCode != http.StatusOK {
+		t.Errorf("served engine: status %d", resp.StatusCode)
+	}
+	got := decode[map[string]any](t, get(t, s, "/engines"))
+	if list := got["engines"].([]any); len(list) != 2 || list[0] != "fake" || list[1] != "fake-plain" {
+		t.Errorf("engines = %v", got)
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	resp := get(t, &Server{Engine: "fake"}, "/suggest?q=go")
+	if ct := resp.Header.Get("Content-Type"); ct != "application/x-suggestions+json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	got := decode[[]any](t, resp)
+	if len(got) != 2 || got[0] != "go" {
+		t.Fatalf("suggestions = %v", got)
+	}
+	if list := got[1].([]any); len(list) != 2 || list[0] != "golang" {
+		t.Errorf("suggestions = %v", list)
+	}
+
+	// Engines without completions answer with an empty list
+	got = decode[[]any](t, get(t, &Server{Engine: "fake-plain"}, "/suggest?q=go"))
+	if list, ok := got[1].([]any); !ok || len(list) != 0 {
+		t.Errorf("suggestions = %v, want []", got)
+	}
+
+	if resp := get(t, &Server{Engine: "fake"}, "/suggest?q=go&engine=nope"); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("unknown engine: status %d", resp.StatusCode)
+	}
+}
+
+func TestOpenSearch(t *testing.T) {
+	req := httptest.NewRequest("GET", "/opensearch.xml", nil)
+	req.Host = "search.example:8080"
+	req.Header.Set("X-Forwarded-Proto", "https")
+	rec := httptest.NewRecorder()
+	(&Server{Engine: "fake"}).Handler().ServeHTTP(rec, req)
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/opensearchdescription+xml" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		`template="https://search.example:8080/search?q={searchTerms}&amp;format=html"`,
+		`template="https://search.example:8080/suggest?q={searchTerms}"`,
+		"ksk web search (fake)",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("missing %s in\n%s", want, body)
+		}
+	}
+}
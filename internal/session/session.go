@@ -0,0 +1,141 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/frort/ksk/internal/config"
+	"github.com/frort/ksk/internal/search"
+)
+
+// Version is bumped when the file format changes incompatibly.
+const Version = 2
+
+// LastName is the session used by the automatic resume option.
+const LastName = "last"
+
+// ErrNotFound is returned by Load for a session that was never saved.
+var ErrNotFound = errors.New("session not found")
+
+// Session is the saved state of a TUI run.
+type Session struct {
+	Version int       `json:"version"`
+	Saved   time.Time `json:"saved"`
+
+	Tabs     []Tab    `json:"tabs"`
+	Active   int      `json:"active"` // index of the tab being viewed
+	Language string   `json:"language,omitempty"`
+	History  []string `json:"history,omitempty"`
+}
+
+// Tab is the saved state of one search tab. The JSON names are those of
+// version 1, which held a single tab at the top level.
+type Tab struct {
+	Query      string            `json:"query"`
+	Engine     string            `json:"engine"`
+	Region     string            `json:"region,omitempty"`
+	SafeSearch search.SafeSearch `json:"safe_search,omitempty"`
+	Pages      []*search.Page    `json:"pages,omitempty"` // every page loaded for Query
+	PageNum    int               `json:"page"`            // page being viewed
+	Cursor     int               `json:"cursor"`
+	Filter     string            `json:"filter,omitempty"`
+}
+
+// ActiveTab returns the tab being viewed, or nil when there is none.
+func (s *Session) ActiveTab() *Tab {
+	if s.Active < 0 || s.Active >= len(s.Tabs) {
+		return nil
+	}
+	return &s.Tabs[s.Active]
+}
+
+// Empty reports whether no tab has a query, leaving nothing worth saving.
+func (s *Session) Empty() bool {
+	for _, t := range s.Tabs {
+		if t.Query != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Page returns the loaded page numbered n, or nil.
+func (t *Tab) Page(n int) *search.Page {
+	for _, p := range t.Pages {
+		if p.PageNum == n {
+			return p
+		}
+	}
+	return nil
+}
+
+// Path returns the file for the named session.
+func Path(name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid session name %q", name)
+	}
+	d, err := config.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(d, "sessions", name+".json"), nil
+}
+
+// Load reads the named session.
+func Load(name string) (*Session, error) {
+	path, err := Path(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading session: %w", err)
+	}
+	var s Session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	switch s.Version {
+	case Version:
+	case 1:
+		var t Tab
+		if err := json.Unmarshal(data, &t); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", path, err)
+		}
+		s.Tabs, s.Active = []Tab{t}, 0
+	default:
+		return nil, fmt.Errorf("session %s has unsupported version %d", name, s.Version)
+	}
+	return &s, nil
+}
+
+// Save writes s as the named session.
+func Save(name string, s *Session) error {
+	path, err := Path(name)
+	if err != nil {
+		return err
+	}
+	s.Version = Version
+	s.Saved = time.Now()
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
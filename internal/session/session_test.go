@@ -0,0 +1,86 @@
+package session
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/frort/ksk/internal/search"
+)
+
+func TestSaveLoad(t *testing.T) {
+	t.Setenv("KSK_DATA_DIR", t.TempDir())
+	want := &Session{
+		Tabs: []Tab{
+			{
+				Query:   "golang",
+				Engine:  "brave",
+				Region:  "jp",
+				PageNum: 2,
+				Cursor:  3,
+				Filter:  "tour",
+				Pages: []*search.Page{
+					{PageNum: 1, HasMore: true, Results: []search.Result{{Title: "Go", URL: "https://go.dev/"}}},
+					{PageNum: 2, Results: []search.Result{{Title: "A Tour of Go", URL: "https://go.dev/tour/"}}},
+				},
+			},
+			{Query: "rust", Engine: "duckduckgo", SafeSearch: search.SafeStrict},
+		},
+		Active:   1,
+		Language: "ja",
+		History:  []string{"golang", "rust"},
+	}
+	if err := Save("work", want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load("work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != Version || !got.Saved.Equal(want.Saved) {
+		t.Errorf("version %d, saved %v", got.Version, got.Saved)
+	}
+	got.Saved = want.Saved
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("loaded %+v\nwant %+v", got, want)
+	}
+	if p := got.ActiveTab(); p == nil || p.Query != "rust" {
+		t.Errorf("active tab = %+v", p)
+	}
+	if p := got.Tabs[0].Page(2); p == nil || p.Results[0].Title != "A Tour of Go" {
+		t.Errorf("page 2 = %+v", p)
+	}
+}
+
+func TestLoadVersion1(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("KSK_DATA_DIR", dir)
+	v1 := `{"version": 1, "query": "golang", "engine": "brave", "region": "jp", "page": 1,
+		"pages": [{"page": 1, "results": [{"title": "Go", "url": "https://go.dev/"}]}], "history": ["golang"]}`
+	if err := os.MkdirAll(filepath.Join(dir, "sessions"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "sessions", "old.json"), []byte(v1), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Load("old")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tab := s.ActiveTab()
+	if len(s.Tabs) != 1 || tab.Query != "golang" || tab.Engine != "brave" || tab.Region != "jp" || tab.Page(1) == nil {
+		t.Errorf("tabs = %+v", s.Tabs)
+	}
+	if !reflect.DeepEqual(s.History, []string{"golang"}) {
+		t.Errorf("history = %q", s.History)
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	t.Setenv("KSK_DATA_DIR", t.TempDir())
+	if _, err := Load("nope"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Load = %v, want ErrNotFound", err)
+	}
+}
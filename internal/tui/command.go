@@ -0,0 +1,374 @@
+package tui
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/frort/ksk/internal/browser"
+	"github.com/frort/ksk/internal/search"
+)
+
+// command is an ex-style command run from the ":" prompt.
+type command struct {
+	name    string
+	aliases []string
+	run     func(m Model, args string) (tea.Model, tea.Cmd)
+	// complete lists the candidates for the argument, if any
+	complete func(m Model) []string
+}
+
+var commands = []command{
+	{name: "engine", aliases: []string{"e"}, run: Model.cmdEngine,
+		complete: func(Model) []string { return search.Engines() }},
+	{name: "region", aliases: []string{"r"}, run: Model.cmdRegion,
+		complete: func(m Model) []string {
+			var codes []string
+			for _, r := range search.Regions() {
+				if supportsRegion(m.backend, r.Code) {
+					codes = append(codes, r.Code)
+				}
+			}
+			return codes
+		}},
+	{name: "page", aliases: []string{"p"}, run: Model.cmdPage},
+	{name: "open", aliases: []string{"o"}, run: Model.cmdOpen},
+	{name: "yank", aliases: []string{"y"}, run: Model.cmdYank,
+		complete: func(Model) []string { return []string{"all"} }},
+	{name: "filter", aliases: []string{"f"}, run: Model.cmdFilter},
+	{name: "set", run: Model.cmdSet,
+		complete: func(Model) []string {
+			return []string{"safe=off", "safe=moderate", "safe=strict", "ads=on", "ads=off"}
+		}},
+	{name: "tabnew", aliases: []string{"tabe", "tabedit"}, run: Model.openTab},
+	{name: "tabclose", aliases: []string{"tabc"}, run: func(m Model, _ string) (tea.Model, tea.Cmd) {
+		return m.closeTab()
+	}},
+	{name: "tabnext", aliases: []string{"tabn"}, run: func(m Model, _ string) (tea.Model, tea.Cmd) {
+		return m.switchTab(m.active + 1)
+	}},
+	{name: "tabprevious", aliases: []string{"tabp", "tabN"}, run: func(m Model, _ string) (tea.Model, tea.Cmd) {
+		return m.switchTab(m.active - 1)
+	}},
+	{name: "quit", aliases: []string{"q"}, run: func(m Model, _ string) (tea.Model, tea.Cmd) {
+		return m, tea.Quit
+	}},
+}
+
+func lookupCommand(name string) (command, bool) {
+	for _, c := range commands {
+		if c.name == name || slices.Contains(c.aliases, name) {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+func newCommandLine() textinput.Model {
+	ti := textinput.New()
+	ti.Prompt = ":"
+	ti.PromptStyle = promptStyle
+	ti.CharLimit = 256
+	return ti
+}
+
+func (m Model) updateCommand(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		m.cmdHint = ""
+		switch msg.Type {
+		case tea.KeyEsc, tea.KeyCtrlC:
+			m.closeCommandLine()
+			return m, nil
+		case tea.KeyBackspace:
+			if m.cmdline.Value() == "" {
+				m.closeCommandLine()
+				return m, nil
+			}
+		case tea.KeyTab:
+			m.completeCommand()
+			return m, nil
+		case tea.KeyEnter:
+			line := m.cmdline.Value()
+			m.closeCommandLine()
+			return m.execCommand(line)
+		}
+	}
+	var cmd tea.Cmd
+	m.cmdline, cmd = m.cmdline.Update(msg)
+	return m, cmd
+}
+
+func (m *Model) openCommandLine() tea.Cmd {
+	m.commanding = true
+	m.cmdHint = ""
+	m.cmdline.SetValue("")
+	return m.cmdline.Focus()
+}
+
+func (m *Model) closeCommandLine() {
+	m.commanding = false
+	m.cmdHint = ""
+	m.cmdline.Blur()
+}
+
+// completeCommand completes the command name or its argument at the end of
+// the line. Several matches are extended to their common prefix and listed
+// above the prompt.
+func (m *Model) completeCommand() {
+	line := m.cmdline.Value()
+	name, arg, hasArg := strings.Cut(line, " ")
+
+	var candidates []string
+	prefix, base := name, ""
+	if hasArg {
+		c, ok := lookupCommand(name)
+		if !ok || c.complete == nil {
+			return
+		}
+		candidates = c.complete(*m)
+		prefix, base = arg, name+" "
+	} else {
+		for _, c := range commands {
+			candidates = append(candidates, c.name)
+		}
+	}
+
+	var matches []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, prefix) {
+			matches = append(matches, c)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		m.cmdHint = "no completions"
+	case 1:
+		completed := base + matches[0]
+		if !hasArg {
+			completed += " "
+		}
+		m.cmdline.SetValue(completed)
+	default:
+		m.cmdline.SetValue(base + commonPrefix(matches))
+		m.cmdHint = strings.Join(matches, "  ")
+	}
+	m.cmdline.CursorEnd()
+}
+
+func commonPrefix(list []string) string {
+	prefix := list[0]
+	for _, s := range list[1:] {
+		for !strings.HasPrefix(s, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
+
+// execCommand runs one command line, e.g. "engine brave".
+func (m Model) execCommand(line string) (tea.Model, tea.Cmd) {
+	name, args, _ := strings.Cut(strings.TrimSpace(line), " ")
+	if name == "" {
+		return m, nil
+	}
+	c, ok := lookupCommand(name)
+	if !ok {
+		m.errMsg = fmt.Sprintf("unknown command: %s", name)
+		return m, nil
+	}
+	m.errMsg = ""
+	return c.run(m, strings.TrimSpace(args))
+}
+
+func (m Model) usageError(usage string) (tea.Model, tea.Cmd) {
+	m.errMsg = "usage: :" + usage
+	return m, nil
+}
+
+// rerun searches the current query again, e.g. after a setting changed.
+func (m Model) rerun() (tea.Model, tea.Cmd) {
+	if m.query == "" {
+		return m, nil
+	}
+	return m.runQuery(m.query, false)
+}
+
+func (m Model) cmdEngine(args string) (tea.Model, tea.Cmd) {
+	if args == "" {
+		return m.usageError("engine NAME")
+	}
+	backend, err := m.newBackend(args)
+	if err != nil {
+		m.errMsg = err.Error()
+		return m, nil
+	}
+	m.backend = backend
+	return m.rerun()
+}
+
+// cmdRegion switches the tab to region, keeping the current one when the
+// engine does not support it.
+func (m Model) cmdRegion(args string) (tea.Model, tea.Cmd) {
+	backend, err := m.newTabBackend(m.backend.Name(), args, m.safe)
+	if err != nil {
+		m.errMsg = err.Error()
+		return m, nil
+	}
+	m.backend, m.region = backend, args
+	return m.rerun()
+}
+
+func (m Model) cmdPage(args string) (tea.Model, tea.Cmd) {
+	n, err := strconv.Atoi(args)
+	if err != nil || n < 1 {
+		return m.usageError("page N")
+	}
+	if m.query == "" {
+		m.errMsg = "no search to page through"
+		return m, nil
+	}
+	if p, ok := m.pages[n]; ok {
+		m.showPage(p, m.opts.ShowAds)
+		m.state = stateResults
+		return m, nil
+	}
+	m.state = stateLoading
+	return m, tea.Batch(m.spinner.Tick, m.doPage(n))
+}
+
+// resultArg resolves a 1-based result number, or the selected result when
+// args is empty.
+func (m Model) resultArg(args string) (*search.Result, error) {
+	if args == "" {
+		if r := m.results.SelectedResult(); r != nil {
+			return r, nil
+		}
+		return nil, fmt.Errorf("no result selected")
+	}
+	n, err := strconv.Atoi(args)
+	if err != nil {
+		return nil, fmt.Errorf("not a result number: %s", args)
+	}
+	if n < 1 || n > len(m.results.results) {
+		return nil, fmt.Errorf("no result %d (1-%d)", n, len(m.results.results))
+	}
+	return &m.results.results[n-1], nil
+}
+
+func (m Model) cmdOpen(args string) (tea.Model, tea.Cmd) {
+	r, err := m.resultArg(args)
+	if err != nil {
+		m.errMsg = err.Error()
+		return m, nil
+	}
+	if err := browser.Open(m.cleanURL(r.URL)); err != nil {
+		m.errMsg = err.Error()
+		return m, nil
+	}
+	return m, m.recordOpen(r.URL)
+}
+
+func (m Model) cmdYank(args string) (tea.Model, tea.Cmd) {
+	var urls []string
+	if args == "all" {
+		for _, r := range m.results.results {
+			urls = append(urls, m.cleanURL(r.URL))
+		}
+	} else {
+		r, err := m.resultArg(args)
+		if err != nil {
+			m.errMsg = err.Error()
+			return m, nil
+		}
+		urls = append(urls, m.cleanURL(r.URL))
+	}
+	if len(urls) == 0 {
+		m.errMsg = "no results to copy"
+		return m, nil
+	}
+	if err := clipboard.WriteAll(strings.Join(urls, "\n")); err != nil {
+		m.errMsg = err.Error()
+		return m, nil
+	}
+	m.notice = fmt.Sprintf("Copied %d URL(s)", len(urls))
+	return m, nil
+}
+
+// cmdFilter narrows the shown results to those matching args, or shows
+// them all again when args is empty.
+func (m Model) cmdFilter(args string) (tea.Model, tea.Cmd) {
+	m.filter = args
+	if m.page != nil {
+		m.showPage(m.page, m.opts.ShowAds)
+	}
+	return m, nil
+}
+
+func (m Model) cmdSet(args string) (tea.Model, tea.Cmd) {
+	if args == "" {
+		m.notice = fmt.Sprintf("safe=%s ads=%s", cmp.Or(string(m.safe), "default"), onOff(m.opts.ShowAds))
+		return m, nil
+	}
+	safe, rerun, redraw := m.safe, false, false
+	for _, kv := range strings.Fields(args) {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok {
+			return m.usageError("set safe=off|moderate|strict ads=on|off")
+		}
+		switch key {
+		case "safe":
+			s, err := search.ParseSafeSearch(value)
+			if err != nil {
+				m.errMsg = err.Error()
+				return m, nil
+			}
+			safe, rerun = s, true
+		case "ads":
+			show, err := parseOnOff(value)
+			if err != nil {
+				m.errMsg = "ads: " + err.Error()
+				return m, nil
+			}
+			m.opts.ShowAds = show
+			redraw = true
+		default:
+			m.errMsg = fmt.Sprintf("unknown option: %s", key)
+			return m, nil
+		}
+	}
+	if rerun {
+		backend, err := m.newTabBackend(m.backend.Name(), m.region, safe)
+		if err != nil {
+			m.errMsg = err.Error()
+			return m, nil
+		}
+		m.backend, m.safe = backend, safe
+		return m.rerun()
+	}
+	if redraw && m.page != nil {
+		m.showPage(m.page, m.opts.ShowAds)
+	}
+	return m, nil
+}
+
+func parseOnOff(s string) (bool, error) {
+	switch s {
+	case "on", "true", "yes", "1":
+		return true, nil
+	case "off", "false", "no", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("want on or off, got %q", s)
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
@@ -0,0 +1,307 @@
+package tui
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/frort/ksk/internal/browser"
+	"github.com/frort/ksk/internal/search"
+)
+
+type compareResultMsg struct {
+	col  int
+	page *search.Page
+	err  error
+}
+
+// compareColumn is one engine's result list in compare mode.
+type compareColumn struct {
+	backend search.Backend
+	results resultsModel
+	loading bool
+	err     string
+}
+
+// CompareModel runs one query on several engines and shows the result lists
+// side by side. Results found by more than one engine carry a badge with
+// their rank there.
+type CompareModel struct {
+	query   string
+	cols    []compareColumn
+	focus   int
+	spinner spinner.Model
+	width   int
+	height  int
+	opts    Options
+	keys    KeyMap
+	notice  string // last Recorder failure
+}
+
+func NewCompareModel(query string, backends []search.Backend, opts Options) CompareModel {
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = spinnerStyle
+
+	m := CompareModel{query: query, spinner: s, opts: opts, keys: opts.keyMap()}
+	for _, b := range backends {
+		m.cols = append(m.cols, compareColumn{backend: b, results: newResultsModel(opts), loading: true})
+	}
+	m.setFocus(0)
+	return m
+}
+
+func (m CompareModel) Init() tea.Cmd {
+	cmds := []tea.Cmd{m.spinner.Tick}
+	for i, c := range m.cols {
+		backend := c.backend
+		cmds = append(cmds, func() tea.Msg {
+			page, err := backend.Search(m.query)
+			return compareResultMsg{col: i, page: page, err: err}
+		})
+	}
+	return tea.Batch(cmds...)
+}
+
+func (m CompareModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.layout()
+		return m, nil
+
+	case compareResultMsg:
+		c := &m.cols[msg.col]
+		c.loading = false
+		if msg.err != nil {
+			c.err = msg.err.Error()
+			return m, nil
+		}
+		c.results.SetResults(filterAds(msg.page.Results, m.opts.ShowAds), msg.page.PageNum, false)
+		m.annotate()
+		m.syncCursors()
+		return m, m.record(c.backend.Name(), msg.page.Results)
+
+	case recordErrMsg:
+		m.notice = "Index: " + msg.err.Error()
+		return m, nil
+
+	case spinner.TickMsg:
+		if m.loading() {
+			var cmd tea.Cmd
+			m.spinner, cmd = m.spinner.Update(msg)
+			return m, cmd
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		col := &m.cols[m.focus].results
+		// The page keys move between columns, as there is one page per engine
+		switch {
+		case key.Matches(msg, m.keys.Quit):
+			return m, tea.Quit
+		case key.Matches(msg, m.keys.Down):
+			col.CursorDown()
+			m.syncCursors()
+		case key.Matches(msg, m.keys.Up):
+			col.CursorUp()
+			m.syncCursors()
+		case key.Matches(msg, m.keys.Top):
+			col.CursorTop()
+			m.syncCursors()
+		case key.Matches(msg, m.keys.Bottom):
+			col.CursorBottom()
+			m.syncCursors()
+		case key.Matches(msg, m.keys.NextPage), msg.Type == tea.KeyTab:
+			m.setFocus((m.focus + 1) % len(m.cols))
+		case key.Matches(msg, m.keys.PrevPage), msg.Type == tea.KeyShiftTab:
+			m.setFocus((m.focus - 1 + len(m.cols)) % len(m.cols))
+		case key.Matches(msg, m.keys.Open):
+			if r := col.SelectedResult(); r != nil {
+				_ = browser.Open(m.cleanURL(r.URL))
+			}
+		case key.Matches(msg, m.keys.Yank):
+			if r := col.SelectedResult(); r != nil {
+				_ = clipboard.WriteAll(m.cleanURL(r.URL))
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m CompareModel) loading() bool {
+	for _, c := range m.cols {
+		if c.loading {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *CompareModel) setFocus(i int) {
+	m.focus = i
+	for j := range m.cols {
+		m.cols[j].results.inactive = j != i
+	}
+}
+
+// layout divides the window width between the columns.
+func (m *CompareModel) layout() {
+	w := m.width / max(1, len(m.cols))
+	// Reserve space for column header(1) + status(1) + padding(2)
+	for i := range m.cols {
+		m.cols[i].results.SetSize(w, m.height-4)
+		m.cols[i].results.ensureVisible()
+	}
+}
+
+// annotate badges every result that other engines returned too with its
+// rank there, e.g. "brave #3 +2" when it ranks two places lower on Brave.
+func (m *CompareModel) annotate() {
+	ranks := make([]map[string]int, len(m.cols))
+	for i, c := range m.cols {
+		ranks[i] = make(map[string]int)
+		for j, r := range c.results.results {
+			k := m.compareKey(r.URL)
+			if _, ok := ranks[i][k]; !ok {
+				ranks[i][k] = j + 1
+			}
+		}
+	}
+	for i := range m.cols {
+		c := &m.cols[i]
+		notes := make([]string, len(c.results.results))
+		for j, r := range c.results.results {
+			k := m.compareKey(r.URL)
+			var parts []string
+			for o, other := range m.cols {
+				if o == i {
+					continue
+				}
+				if rank, ok := ranks[o][k]; ok {
+					parts = append(parts, fmt.Sprintf("%s #%d %s", other.backend.Name(), rank, rankDiff(rank-(j+1))))
+				}
+			}
+			notes[j] = strings.Join(parts, ", ")
+		}
+		c.results.SetNotes(notes)
+	}
+}
+
+func rankDiff(d int) string {
+	switch {
+	case d > 0:
+		return fmt.Sprintf("+%d", d)
+	case d < 0:
+		return fmt.Sprintf("%d", d)
+	}
+	return "="
+}
+
+// syncCursors moves the other columns to the focused result, when they
+// have it too.
+func (m *CompareModel) syncCursors() {
+	r := m.cols[m.focus].results.SelectedResult()
+	if r == nil {
+		return
+	}
+	k := m.compareKey(r.URL)
+	for i := range m.cols {
+		if i == m.focus {
+			continue
+		}
+		for j, other := range m.cols[i].results.results {
+			if m.compareKey(other.URL) == k {
+				m.cols[i].results.SetCursor(j)
+				break
+			}
+		}
+	}
+}
+
+// compareKey normalizes a URL so that the same page matches across engines
+// regardless of tracking parameters, scheme, "www." or a trailing slash.
+func (m CompareModel) compareKey(raw string) string {
+	u, err := url.Parse(m.cleanURL(raw))
+	if err != nil {
+		return raw
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	key := host + strings.TrimSuffix(u.EscapedPath(), "/")
+	if u.RawQuery != "" {
+		key += "?" + u.RawQuery
+	}
+	return key
+}
+
+func (m CompareModel) cleanURL(u string) string {
+	if m.opts.Sanitizer == nil {
+		return u
+	}
+	return m.opts.Sanitizer.Clean(u)
+}
+
+func (m CompareModel) record(engine string, results []search.Result) tea.Cmd {
+	rec := m.opts.Recorder
+	if rec == nil {
+		return nil
+	}
+	query := m.query
+	return func() tea.Msg {
+		if err := rec.Record(query, engine, results); err != nil {
+			return recordErrMsg{err}
+		}
+		return nil
+	}
+}
+
+func (m CompareModel) View() string {
+	w := m.width / max(1, len(m.cols))
+	views := make([]string, len(m.cols))
+	shared := 0
+	for i, c := range m.cols {
+		style := compareHeaderStyle
+		if i == m.focus {
+			style = compareHeaderFocusedStyle
+		}
+		header := c.backend.Name()
+		var body string
+		switch {
+		case c.loading:
+			body = fmt.Sprintf("\n  %s Searching...\n", m.spinner.View())
+		case c.err != "":
+			body = errorStyle.Render(truncate("Error: "+c.err, max(1, w-4)))
+		default:
+			header += fmt.Sprintf(" (%d)", len(c.results.results))
+			body = c.results.View()
+		}
+		if i == 0 {
+			for _, n := range c.results.notes {
+				if n != "" {
+					shared++
+				}
+			}
+		}
+		views[i] = lipgloss.NewStyle().Width(w).MaxHeight(m.height - 1).Render(
+			lipgloss.JoinVertical(lipgloss.Left, style.Render(truncate(header, max(1, w-2))), body))
+	}
+
+	status := fmt.Sprintf("%q | %d shared | ", m.query, shared)
+	if m.notice != "" {
+		status += m.notice + " | "
+	}
+	status += newHelp().ShortHelpView([]key.Binding{
+		pairBinding(m.keys.PrevPage, m.keys.NextPage, "column"),
+		pairBinding(m.keys.Down, m.keys.Up, "move"),
+		m.keys.Open, m.keys.Yank, m.keys.Quit,
+	})
+	return lipgloss.JoinVertical(lipgloss.Left,
+		lipgloss.JoinHorizontal(lipgloss.Top, views...),
+		statusBar.Render(truncate(status, max(1, m.width-2))))
+}
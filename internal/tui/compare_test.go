@@ -0,0 +1,72 @@
+package tui
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/frort/ksk/internal/search"
+)
+
+// namedBackend is a backend that only has a name.
+type namedBackend struct {
+	suggestBackend
+	name string
+}
+
+func (b namedBackend) Name() string { return b.name }
+
+func TestCompareKey(t *testing.T) {
+	m := NewCompareModel("", nil, Options{Sanitizer: search.NewSanitizer()})
+	tests := []struct {
+		a, b string
+		same bool
+	}{
+		{"https://go.dev/doc/", "http://go.dev/doc", true},
+		{"https://www.Go.dev/doc", "https://go.dev/doc", true},
+		{"https://go.dev/?utm_source=x", "https://go.dev", true},
+		{"https://go.dev/?q=1", "https://go.dev/?q=2", false},
+		{"https://go.dev/doc", "https://go.dev/blog", false},
+		{"https://go.dev:8080/", "https://go.dev/", false},
+	}
+	for _, tt := range tests {
+		ka, kb := m.compareKey(tt.a), m.compareKey(tt.b)
+		if (ka == kb) != tt.same {
+			t.Errorf("compareKey(%q) = %q, compareKey(%q) = %q, same = %v", tt.a, ka, tt.b, kb, tt.same)
+		}
+	}
+}
+
+func TestRankDiff(t *testing.T) {
+	for d, want := range map[int]string{0: "=", 2: "+2", -3: "-3"} {
+		if got := rankDiff(d); got != want {
+			t.Errorf("rankDiff(%d) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestAnnotate(t *testing.T) {
+	results := func(urls ...string) []search.Result {
+		var rs []search.Result
+		for _, u := range urls {
+			rs = append(rs, search.Result{URL: u})
+		}
+		return rs
+	}
+	m := NewCompareModel("", []search.Backend{namedBackend{name: "ddg"}, namedBackend{name: "brave"}, namedBackend{name: "mojeek"}}, Options{})
+	m.cols[0].results.SetResults(results("https://a.example/", "https://b.example/", "https://c.example/"), 1, false)
+	m.cols[1].results.SetResults(results("https://b.example", "https://www.a.example/", "https://a.example/"), 1, false)
+	m.cols[2].results.SetResults(results("https://d.example/", "https://e.example/", "https://b.example/"), 1, false)
+	m.annotate()
+
+	want := [][]string{
+		{"brave #2 +1", "brave #1 -1, mojeek #3 +1", ""},
+		// The first of duplicate results counts
+		{"ddg #2 +1, mojeek #3 +2", "ddg #1 -1", "ddg #1 -2"},
+		{"", "", "ddg #2 -1, brave #1 -2"},
+	}
+	for i, c := range m.cols {
+		if !slices.Equal(c.results.notes, want[i]) {
+			t.Errorf("%s notes = %q, want %q", c.backend.Name(), c.results.notes, want[i])
+		}
+	}
+}
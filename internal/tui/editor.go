@@ -0,0 +1,73 @@
+package tui
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// editorDoneMsg carries the query back from the external editor.
+type editorDoneMsg struct {
+	query string
+	err   error
+}
+
+// editQuery opens the query in the user's editor, handing it the terminal
+// until it exits. The saved text replaces the query in the prompt.
+func (m Model) editQuery() tea.Cmd {
+	args, err := editorArgs(m.opts.Editor)
+	if err != nil {
+		return editorError(err)
+	}
+	f, err := os.CreateTemp("", "ksk-query-*.txt")
+	if err != nil {
+		return editorError(err)
+	}
+	path := f.Name()
+	_, err = f.WriteString(m.input.Value() + "\n")
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+		return editorError(err)
+	}
+
+	c := exec.Command(args[0], append(args[1:], path)...)
+	return tea.ExecProcess(c, func(err error) tea.Msg {
+		defer os.Remove(path)
+		if err != nil {
+			return editorDoneMsg{err: fmt.Errorf("editor: %w", err)}
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return editorDoneMsg{err: fmt.Errorf("editor: %w", err)}
+		}
+		return editorDoneMsg{query: strings.TrimSpace(string(data))}
+	})
+}
+
+// editorArgs splits the first editor set among the configured one, $VISUAL
+// and $EDITOR into a command and its arguments. Blank settings are skipped.
+func editorArgs(configured string) ([]string, error) {
+	args := strings.Fields(cmp.Or(
+		strings.TrimSpace(configured),
+		strings.TrimSpace(os.Getenv("VISUAL")),
+		strings.TrimSpace(os.Getenv("EDITOR")),
+	))
+	if len(args) == 0 {
+		return nil, errors.New("no editor configured (set editor, $VISUAL or $EDITOR)")
+	}
+	return args, nil
+}
+
+func editorError(err error) tea.Cmd {
+	return func() tea.Msg {
+		return editorDoneMsg{err: fmt.Errorf("editor: %w", err)}
+	}
+}
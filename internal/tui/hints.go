@@ -0,0 +1,105 @@
+package tui
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxCount caps the numeric prefix typed before a key, e.g. "5j".
+const maxCount = 9999
+
+// hintAlphabet holds the letters used for hint labels, home row first.
+const hintAlphabet = "asdfghjkl"
+
+// hintAction is what picking a hint label does.
+type hintAction int
+
+const (
+	hintNone hintAction = iota
+	hintOpen
+	hintYank
+)
+
+// hintLabels returns n distinct labels of equal length, so that no label is
+// a prefix of another.
+func hintLabels(n int) []string {
+	labels := make([]string, 0, n)
+	if n <= len(hintAlphabet) {
+		for _, c := range hintAlphabet[:n] {
+			labels = append(labels, string(c))
+		}
+		return labels
+	}
+	for _, a := range hintAlphabet {
+		for _, b := range hintAlphabet {
+			if len(labels) == n {
+				return labels
+			}
+			labels = append(labels, string(a)+string(b))
+		}
+	}
+	return labels
+}
+
+// startHint labels the visible results and waits for one label to be typed.
+func (m *Model) startHint(action hintAction) {
+	if len(m.results.results) == 0 {
+		return
+	}
+	first := m.results.offset
+	visible := m.results.visibleFrom(first)
+	hints := make([]string, len(m.results.results))
+	for i, l := range hintLabels(visible) {
+		hints[first+i] = l
+	}
+	m.results.SetHints(hints)
+	m.hint = action
+	m.hintKeys = ""
+}
+
+func (m *Model) endHint() {
+	m.hint = hintNone
+	m.hintKeys = ""
+	m.results.SetHints(nil)
+}
+
+// updateHint narrows the hint labels by the letters typed. A complete label
+// opens or copies its result; anything that matches no label cancels.
+func (m Model) updateHint(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	if key.Type != tea.KeyRunes {
+		m.endHint()
+		return m, nil
+	}
+	m.hintKeys += strings.ToLower(string(key.Runes))
+	if i := slices.Index(m.results.hints, m.hintKeys); i >= 0 {
+		action := m.hint
+		m.endHint()
+		if action == hintYank {
+			return m.cmdYank(strconv.Itoa(i + 1))
+		}
+		return m.cmdOpen(strconv.Itoa(i + 1))
+	}
+	if !slices.ContainsFunc(m.results.hints, func(l string) bool {
+		return l != "" && strings.HasPrefix(l, m.hintKeys)
+	}) {
+		m.endHint()
+	}
+	return m, nil
+}
+
+// countKey adds a digit key to the pending count. "0" only counts after
+// another digit.
+func (m *Model) countKey(key string) bool {
+	if len(key) != 1 || key[0] < '0' || key[0] > '9' || (key == "0" && m.count == 0) {
+		return false
+	}
+	m.count = min(m.count*10+int(key[0]-'0'), maxCount)
+	return true
+}
@@ -0,0 +1,71 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestHintLabels(t *testing.T) {
+	tests := []struct {
+		n           int
+		first, last string
+		width, want int
+	}{
+		{0, "", "", 0, 0},
+		{1, "a", "a", 1, 1},
+		{9, "a", "l", 1, 9},
+		{10, "aa", "sa", 2, 10},
+		{81, "aa", "ll", 2, 81},
+		// Two letters are all there is
+		{100, "aa", "ll", 2, 81},
+	}
+	for _, tt := range tests {
+		labels := hintLabels(tt.n)
+		if len(labels) != tt.want {
+			t.Errorf("hintLabels(%d) returned %d labels, want %d", tt.n, len(labels), tt.want)
+			continue
+		}
+		if tt.n == 0 {
+			continue
+		}
+		if labels[0] != tt.first || labels[len(labels)-1] != tt.last {
+			t.Errorf("hintLabels(%d) = %q...%q, want %q...%q", tt.n, labels[0], labels[len(labels)-1], tt.first, tt.last)
+		}
+		for i, l := range labels {
+			if len(l) != tt.width {
+				t.Errorf("hintLabels(%d)[%d] = %q, want %d letters", tt.n, i, l, tt.width)
+			}
+			if slices.ContainsFunc(labels[i+1:], func(o string) bool { return strings.HasPrefix(o, l) }) {
+				t.Errorf("hintLabels(%d): %q is not unique", tt.n, l)
+			}
+		}
+	}
+}
+
+func TestCountKey(t *testing.T) {
+	tests := []struct {
+		keys    []string
+		count   int
+		counted []bool
+	}{
+		{[]string{"5"}, 5, []bool{true}},
+		{[]string{"1", "2"}, 12, []bool{true, true}},
+		// A leading zero is a key of its own
+		{[]string{"0"}, 0, []bool{false}},
+		{[]string{"1", "0"}, 10, []bool{true, true}},
+		{[]string{"j"}, 0, []bool{false}},
+		{[]string{"2", "ctrl+d"}, 2, []bool{true, false}},
+		{[]string{"9", "9", "9", "9", "9"}, maxCount, []bool{true, true, true, true, true}},
+	}
+	for _, tt := range tests {
+		var m Model
+		var counted []bool
+		for _, k := range tt.keys {
+			counted = append(counted, m.countKey(k))
+		}
+		if m.count != tt.count || !slices.Equal(counted, tt.counted) {
+			t.Errorf("keys %q: count %d, counted %v, want %d, %v", tt.keys, m.count, counted, tt.count, tt.counted)
+		}
+	}
+}
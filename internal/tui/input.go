@@ -1,46 +1,352 @@
 package tui
 
 import (
-	"github.com/charmbracelet/bubbles/textinput"
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 )
 
+// maxSuggestions caps the autocomplete dropdown.
+const maxSuggestions = 8
+
+// maxHistoryMatches caps how many history entries lead the dropdown.
+const maxHistoryMatches = 3
+
+// defaultCharLimit caps the query length unless Options.CharLimit is set.
+const defaultCharLimit = 256
+
+// maxInputLines is the most lines the prompt grows to; longer queries scroll.
+const maxInputLines = 5
+
+// killRingSize caps how many killed texts Ctrl+Y and Alt+Y can bring back.
+const killRingSize = 16
+
+const inputPrompt = "ksk> "
+
 type inputModel struct {
-	textInput textinput.Model
+	editor textarea.Model
+	keys   KeyMap
+
+	killRing []string // killed text, most recent last
+	lastKill bool     // the previous key killed text, so the next kill extends it
+	yanked   int      // runes inserted by the previous key when it was a yank
+	yankIdx  int      // position in killRing of the yanked text
+
+	history     []string // past queries, most recent last
+	engine      []string // latest completions from the backend
+	suggestions []string
+	selected    int // -1 when no suggestion is highlighted
 }
 
-func newInputModel(placeholder string) inputModel {
-	ti := textinput.New()
-	ti.Placeholder = placeholder
-	ti.Prompt = "ksk> "
-	ti.PromptStyle = promptStyle
-	ti.Focus()
-	ti.CharLimit = 256
-	return inputModel{textInput: ti}
+func newInputModel(placeholder string, opts Options) inputModel {
+	keys := opts.keyMap()
+	ta := textarea.New()
+	ta.Placeholder = placeholder
+	ta.SetPromptFunc(lipgloss.Width(inputPrompt), func(line int) string {
+		if line == 0 {
+			return inputPrompt
+		}
+		return strings.Repeat(" ", lipgloss.Width(inputPrompt)-2) + "│ "
+	})
+	ta.ShowLineNumbers = false
+	style := textarea.Style{Prompt: promptStyle, Placeholder: suggestionStyle.UnsetPadding()}
+	ta.FocusedStyle, ta.BlurredStyle = style, style
+	ta.CharLimit = cmp.Or(opts.CharLimit, defaultCharLimit)
+	if ta.CharLimit < 0 {
+		ta.CharLimit = 0 // no limit
+	}
+
+	// The editing keys come from the KeyMap; Enter is left to the caller
+	tk := textarea.DefaultKeyMap
+	tk.WordForward = keys.WordForward
+	tk.WordBackward = keys.WordBackward
+	tk.LineStart = keys.LineStart
+	tk.LineEnd = keys.LineEnd
+	tk.DeleteWordBackward = keys.KillWordBackward
+	tk.DeleteWordForward = keys.KillWordForward
+	tk.DeleteBeforeCursor = keys.KillToStart
+	tk.DeleteAfterCursor = keys.KillToEnd
+	tk.InsertNewline = keys.Newline
+	ta.KeyMap = tk
+
+	ta.Focus()
+	m := inputModel{editor: ta, keys: keys, selected: -1}
+	m.resize()
+	return m
 }
 
 func (m inputModel) Update(msg tea.Msg) (inputModel, tea.Cmd) {
+	k, ok := msg.(tea.KeyMsg)
+	if !ok {
+		var cmd tea.Cmd
+		m.editor, cmd = m.editor.Update(msg)
+		return m, cmd
+	}
+
+	switch {
+	case key.Matches(k, m.keys.YankKilled):
+		m.yank()
+		return m, nil
+	case key.Matches(k, m.keys.YankPop):
+		m.yankPop()
+		return m, nil
+	}
+
+	before := m.Value()
 	var cmd tea.Cmd
-	m.textInput, cmd = m.textInput.Update(msg)
+	m.editor, cmd = m.editor.Update(msg)
+	kill := key.Matches(k, m.keys.KillWordBackward, m.keys.KillWordForward, m.keys.KillToStart, m.keys.KillToEnd)
+	if kill {
+		backward := key.Matches(k, m.keys.KillWordBackward, m.keys.KillToStart)
+		m.kill(removed(before, m.Value()), backward)
+	}
+	m.lastKill = kill
+	m.yanked = 0
+	m.resize()
 	return m, cmd
 }
 
+// kill pushes text onto the kill ring. Consecutive kills build one entry,
+// as in Emacs, so that Ctrl+W Ctrl+W yanks back both words.
+func (m *inputModel) kill(text string, backward bool) {
+	if text == "" {
+		return
+	}
+	if m.lastKill && len(m.killRing) > 0 {
+		last := &m.killRing[len(m.killRing)-1]
+		if backward {
+			*last = text + *last
+		} else {
+			*last += text
+		}
+		return
+	}
+	m.killRing = append(m.killRing, text)
+	if len(m.killRing) > killRingSize {
+		m.killRing = m.killRing[1:]
+	}
+}
+
+// yank inserts the most recently killed text at the cursor.
+func (m *inputModel) yank() {
+	m.lastKill = false
+	if len(m.killRing) == 0 {
+		return
+	}
+	m.yankIdx = len(m.killRing) - 1
+	m.insertYank()
+}
+
+// yankPop replaces the text just yanked with the previous kill.
+func (m *inputModel) yankPop() {
+	m.lastKill = false
+	if m.yanked == 0 {
+		return
+	}
+	for range m.yanked {
+		m.editor, _ = m.editor.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	}
+	m.yankIdx = (m.yankIdx - 1 + len(m.killRing)) % len(m.killRing)
+	m.insertYank()
+}
+
+func (m *inputModel) insertYank() {
+	n := m.editor.Length()
+	m.editor.InsertString(m.killRing[m.yankIdx])
+	m.yanked = m.editor.Length() - n
+	m.resize()
+}
+
+// removed returns the text deleted from before to get after.
+func removed(before, after string) string {
+	b, a := []rune(before), []rune(after)
+	if len(b) <= len(a) {
+		return ""
+	}
+	i := 0
+	for i < len(a) && b[i] == a[i] {
+		i++
+	}
+	j := 0
+	for j < len(a)-i && b[len(b)-1-j] == a[len(a)-1-j] {
+		j++
+	}
+	return string(b[i : len(b)-j])
+}
+
+// resize grows the prompt with the query, counting the screen lines of
+// wrapped lines too.
+func (m *inputModel) resize() {
+	rows := 0
+	for _, line := range strings.Split(m.Value(), "\n") {
+		// SetValue on a copy leaves the editor as it is
+		c := m.editor
+		c.SetValue(line)
+		rows += c.LineInfo().Height
+	}
+	m.editor.SetHeight(min(max(1, rows), maxInputLines))
+}
+
+// SetWidth fits the prompt to the window.
+func (m *inputModel) SetWidth(w int) {
+	m.editor.SetWidth(w)
+	m.resize()
+}
+
+// Height is the number of lines the prompt takes, without suggestions.
+func (m inputModel) Height() int {
+	return m.editor.Height()
+}
+
+// Multiline reports whether the query spans several lines.
+func (m inputModel) Multiline() bool {
+	return m.editor.LineCount() > 1
+}
+
 func (m inputModel) View() string {
-	return m.textInput.View()
+	v := m.editor.View()
+	if !m.editor.Focused() || len(m.suggestions) == 0 {
+		return v
+	}
+	lines := []string{v}
+	for i, s := range m.suggestions {
+		if i == m.selected {
+			lines = append(lines, suggestionSelectedStyle.Render("> "+s))
+		} else {
+			lines = append(lines, suggestionStyle.Render("  "+s))
+		}
+	}
+	return strings.Join(lines, "\n")
 }
 
+// Value returns the query as typed, lines and all.
 func (m inputModel) Value() string {
-	return m.textInput.Value()
+	return m.editor.Value()
 }
 
 func (m *inputModel) SetValue(s string) {
-	m.textInput.SetValue(s)
+	m.editor.SetValue(s)
+	m.resize()
 }
 
 func (m *inputModel) Focus() tea.Cmd {
-	return m.textInput.Focus()
+	return m.editor.Focus()
 }
 
 func (m *inputModel) Blur() {
-	m.textInput.Blur()
+	m.editor.Blur()
+	m.ClearSuggestions()
+}
+
+// joinLines turns a query written over several lines into the one line
+// sent to the engine.
+func joinLines(q string) string {
+	var parts []string
+	for _, line := range strings.Split(q, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			parts = append(parts, line)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// AddHistory records a query for history-based suggestions.
+func (m *inputModel) AddHistory(q string) {
+	m.history = slices.DeleteFunc(m.history, func(h string) bool { return h == q })
+	m.history = append(m.history, q)
+}
+
+// historyMatches returns past queries containing prefix, most recent first.
+func (m *inputModel) historyMatches(prefix string) []string {
+	if prefix == "" {
+		return nil
+	}
+	prefix = strings.ToLower(prefix)
+	var out []string
+	for i := len(m.history) - 1; i >= 0 && len(out) < maxHistoryMatches; i-- {
+		h := m.history[i]
+		if h != m.Value() && strings.Contains(strings.ToLower(h), prefix) {
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+// SetSuggestions stores engine completions and refreshes the dropdown.
+func (m *inputModel) SetSuggestions(engine []string) {
+	m.engine = engine
+	m.RefreshSuggestions()
+}
+
+// RefreshSuggestions rebuilds the dropdown for the current value: history
+// matches first, then engine completions that still match what was typed.
+func (m *inputModel) RefreshSuggestions() {
+	value := m.Value()
+	merged := m.historyMatches(value)
+	lower := strings.ToLower(value)
+	for _, s := range m.engine {
+		if len(merged) >= maxSuggestions {
+			break
+		}
+		if s != value && strings.HasPrefix(strings.ToLower(s), lower) && !slices.Contains(merged, s) {
+			merged = append(merged, s)
+		}
+	}
+	// Completions make no sense for a query written over several lines
+	if value == "" || m.Multiline() {
+		merged = nil
+	}
+	m.suggestions = merged
+	m.selected = -1
+}
+
+func (m *inputModel) ClearSuggestions() {
+	m.engine = nil
+	m.suggestions = nil
+	m.selected = -1
+}
+
+func (m *inputModel) HasSuggestions() bool {
+	return len(m.suggestions) > 0
+}
+
+// MoveSelection moves the dropdown highlight by delta, wrapping around.
+func (m *inputModel) MoveSelection(delta int) {
+	n := len(m.suggestions)
+	if n == 0 {
+		return
+	}
+	if m.selected < 0 && delta < 0 {
+		m.selected = n - 1
+		return
+	}
+	m.selected = ((m.selected+delta)%n + n) % n
+}
+
+// Selected returns the highlighted suggestion, or "".
+func (m *inputModel) Selected() string {
+	if m.selected < 0 || m.selected >= len(m.suggestions) {
+		return ""
+	}
+	return m.suggestions[m.selected]
+}
+
+// AcceptSuggestion copies the highlighted (or first) suggestion into the
+// prompt and closes the dropdown.
+func (m *inputModel) AcceptSuggestion() bool {
+	s := m.Selected()
+	if s == "" && len(m.suggestions) > 0 {
+		s = m.suggestions[0]
+	}
+	if s == "" {
+		return false
+	}
+	m.SetValue(s)
+	m.ClearSuggestions()
+	return true
 }
@@ -0,0 +1,237 @@
+package tui
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// KeyMap holds the bindings of the results view and the query prompt. The
+// help overlay and the status bar are generated from it.
+type KeyMap struct {
+	// Results mode
+	Down, Up, Top, Bottom          key.Binding
+	NextPage, PrevPage             key.Binding
+	Open, Yank                     key.Binding
+	HintOpen, HintYank             key.Binding
+	OpenAnswer, YankAnswer         key.Binding
+	NextRelated, PrevRelated       key.Binding
+	SearchRelated, Correction      key.Binding
+	Search, Command                key.Binding
+	NextEngine, PrevEngine, Region key.Binding
+	Expand, Layout                 key.Binding
+	SaveSession, Help, Quit        key.Binding
+
+	// Input mode
+	Submit, Back                     key.Binding
+	NextSuggestion, PrevSuggestion   key.Binding
+	AcceptSuggestion, Interrupt, EOF key.Binding
+
+	// Query editing, readline style
+	WordForward, WordBackward         key.Binding
+	LineStart, LineEnd                key.Binding
+	KillWordBackward, KillWordForward key.Binding
+	KillToStart, KillToEnd            key.Binding
+	YankKilled, YankPop               key.Binding
+	Newline, Editor                   key.Binding
+}
+
+// DefaultKeyMap returns the built-in bindings.
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Down:          newBinding("next result", "j", "down"),
+		Up:            newBinding("previous result", "k", "up"),
+		Top:           newBinding("first result", "g"),
+		Bottom:        newBinding("last result", "G"),
+		NextPage:      newBinding("next page", "l", "right"),
+		PrevPage:      newBinding("previous page", "h", "left"),
+		Open:          newBinding("open in browser", "enter", "o"),
+		Yank:          newBinding("copy URL", "y"),
+		HintOpen:      newBinding("open by hint label", "f"),
+		HintYank:      newBinding("copy by hint label", "F"),
+		OpenAnswer:    newBinding("open answer source", "a"),
+		YankAnswer:    newBinding("copy answer source URL", "A"),
+		NextRelated:   newBinding("next related search", "tab"),
+		PrevRelated:   newBinding("previous related search", "shift+tab"),
+		SearchRelated: newBinding("search related search", "s"),
+		Correction:    newBinding("use spelling suggestion", "c"),
+		Search:        newBinding("new search", "/"),
+		Command:       newBinding("command line", ":"),
+		NextEngine:    newBinding("next engine", "e"),
+		PrevEngine:    newBinding("previous engine", "E"),
+		Region:        newBinding("pick region", "r"),
+		Expand:        newBinding("expand snippet", "x"),
+		Layout:        newBinding("blocks / list", "v"),
+		SaveSession:   newBinding("save session", "ctrl+s"),
+		Help:          newBinding("help", "?"),
+		Quit:          newBinding("quit", "q", "ctrl+c"),
+
+		Submit:           newBinding("search", "enter"),
+		Back:             newBinding("close suggestions / back to results", "esc"),
+		NextSuggestion:   newBinding("next suggestion", "ctrl+n", "down"),
+		PrevSuggestion:   newBinding("previous suggestion", "ctrl+p", "up"),
+		AcceptSuggestion: newBinding("accept suggestion", "tab"),
+		Interrupt:        newBinding("quit", "ctrl+c"),
+		EOF:              newBinding("quit when empty", "ctrl+d"),
+
+		WordForward:      newBinding("word forward", "alt+f", "alt+right", "ctrl+right"),
+		WordBackward:     newBinding("word backward", "alt+b", "alt+left", "ctrl+left"),
+		LineStart:        newBinding("start of line", "ctrl+a", "home"),
+		LineEnd:          newBinding("end of line", "ctrl+e", "end"),
+		KillWordBackward: newBinding("kill word backward", "ctrl+w", "alt+backspace"),
+		KillWordForward:  newBinding("kill word forward", "alt+d", "alt+delete"),
+		KillToStart:      newBinding("kill to start of line", "ctrl+u"),
+		KillToEnd:        newBinding("kill to end of line", "ctrl+k"),
+		YankKilled:       newBinding("paste killed text", "ctrl+y"),
+		YankPop:          newBinding("cycle pasted text", "alt+y"),
+		Newline:          newBinding("new line", "alt+enter", "ctrl+j"),
+		Editor:           newBinding("edit in $EDITOR", "alt+e"),
+	}
+}
+
+func newBinding(desc string, keys ...string) key.Binding {
+	return key.NewBinding(key.WithKeys(keys...), key.WithHelp(helpKeys(keys), desc))
+}
+
+// keyNames shortens key names for help text.
+var keyNames = strings.NewReplacer("down", "↓", "up", "↑", "left", "←", "right", "→", "enter", "Enter",
+	"esc", "Esc", "tab", "Tab", "backspace", "Backspace", "delete", "Delete", "home", "Home", "end", "End",
+	"ctrl+", "Ctrl+", "shift+", "Shift+", "alt+", "Alt+")
+
+func helpKeys(keys []string) string {
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		names[i] = keyNames.Replace(k)
+	}
+	return strings.Join(names, "/")
+}
+
+// keyGroup is a titled set of bindings in the help overlay.
+type keyGroup struct {
+	title    string
+	bindings []*key.Binding
+	names    []string // config names, by binding
+}
+
+// groups lists every binding with its config name, grouped for the help
+// overlay.
+func (k *KeyMap) groups() []keyGroup {
+	return []keyGroup{
+		{"Move", []*key.Binding{&k.Down, &k.Up, &k.Top, &k.Bottom, &k.NextPage, &k.PrevPage},
+			[]string{"down", "up", "top", "bottom", "next_page", "prev_page"}},
+		{"Results", []*key.Binding{&k.Open, &k.Yank, &k.HintOpen, &k.HintYank, &k.OpenAnswer, &k.YankAnswer, &k.Expand, &k.Layout},
+			[]string{"open", "yank", "hint_open", "hint_yank", "open_answer", "yank_answer", "expand", "layout"}},
+		{"Search", []*key.Binding{&k.Search, &k.NextRelated, &k.PrevRelated, &k.SearchRelated, &k.Correction, &k.NextEngine, &k.PrevEngine, &k.Region},
+			[]string{"search", "next_related", "prev_related", "search_related", "correction", "next_engine", "prev_engine", "region"}},
+		{"General", []*key.Binding{&k.Command, &k.SaveSession, &k.Help, &k.Quit},
+			[]string{"command", "save_session", "help", "quit"}},
+		{"Query prompt", []*key.Binding{&k.Submit, &k.Back, &k.NextSuggestion, &k.PrevSuggestion, &k.AcceptSuggestion, &k.Interrupt, &k.EOF},
+			[]string{"submit", "back", "next_suggestion", "prev_suggestion", "accept_suggestion", "interrupt", "eof"}},
+		{"Query editing", []*key.Binding{&k.WordForward, &k.WordBackward, &k.LineStart, &k.LineEnd, &k.KillWordBackward, &k.KillWordForward,
+			&k.KillToStart, &k.KillToEnd, &k.YankKilled, &k.YankPop, &k.Newline, &k.Editor},
+			[]string{"word_forward", "word_backward", "line_start", "line_end", "kill_word_backward", "kill_word_forward",
+				"kill_to_start", "kill_to_end", "yank_killed", "yank_pop", "newline", "editor"}},
+	}
+}
+
+// Rebind replaces the keys of the bindings named in keys, e.g.
+// {"down": ["j", "ctrl+j"]}. The help text follows the new keys.
+func (k *KeyMap) Rebind(keys map[string][]string) error {
+	bindings := map[string]*key.Binding{}
+	for _, g := range k.groups() {
+		for i, b := range g.bindings {
+			bindings[g.names[i]] = b
+		}
+	}
+	for name, ks := range keys {
+		b, ok := bindings[name]
+		if !ok {
+			names := slices.Sorted(maps.Keys(bindings))
+			return fmt.Errorf("unknown key binding %q (use %s)", name, strings.Join(names, ", "))
+		}
+		if len(ks) == 0 {
+			b.SetEnabled(false)
+			continue
+		}
+		b.SetKeys(ks...)
+		b.SetHelp(helpKeys(ks), b.Help().Desc)
+	}
+	return nil
+}
+
+// shortHelp is what the status bar shows in results mode.
+func (k *KeyMap) shortHelp() []key.Binding {
+	return []key.Binding{
+		pairBinding(k.Down, k.Up, "move"),
+		pairBinding(k.PrevPage, k.NextPage, "page"),
+		k.Open, k.HintOpen, k.Search,
+		pairBinding(k.NextEngine, k.PrevEngine, "engine"),
+		k.Help, k.Quit,
+	}
+}
+
+// pairBinding shows two bindings as one help entry, e.g. "j/k move".
+func pairBinding(a, b key.Binding, desc string) key.Binding {
+	first := func(b key.Binding) string {
+		if !b.Enabled() || len(b.Keys()) == 0 {
+			return ""
+		}
+		return keyNames.Replace(b.Keys()[0])
+	}
+	return key.NewBinding(key.WithKeys(append(a.Keys(), b.Keys()...)...),
+		key.WithHelp(strings.Trim(first(a)+"/"+first(b), "/"), desc))
+}
+
+func newHelp() help.Model {
+	h := help.New()
+	h.Styles.ShortKey = helpKeyStyle
+	h.Styles.ShortDesc = statusBar.UnsetPadding()
+	h.Styles.ShortSeparator = statusBar.UnsetPadding()
+	h.Styles.Ellipsis = statusBar.UnsetPadding()
+	h.ShortSeparator = " · "
+	return h
+}
+
+// renderHelp renders the help overlay: every binding, grouped, in as many
+// columns as fit in width.
+func (k *KeyMap) renderHelp(width int) string {
+	var blocks []string
+	for _, g := range k.groups() {
+		keyWidth := 0
+		for _, b := range g.bindings {
+			if b.Enabled() {
+				keyWidth = max(keyWidth, lipgloss.Width(b.Help().Key))
+			}
+		}
+		lines := []string{helpTitleStyle.Render(g.title)}
+		for _, b := range g.bindings {
+			if !b.Enabled() {
+				continue
+			}
+			lines = append(lines, helpKeyStyle.Width(keyWidth+2).Render(b.Help().Key)+helpDescStyle.Render(b.Help().Desc))
+		}
+		blocks = append(blocks, lipgloss.NewStyle().PaddingRight(4).MarginBottom(1).Render(strings.Join(lines, "\n")))
+	}
+
+	// Fill rows left to right, wrapping when the next group does not fit
+	var rows []string
+	var row []string
+	used := 0
+	for _, b := range blocks {
+		w := lipgloss.Width(b)
+		if used+w > width && len(row) > 0 {
+			rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, row...))
+			row, used = nil, 0
+		}
+		row = append(row, b)
+		used += w
+	}
+	rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, row...))
+	footer := helpDescStyle.Render("gt/gT switch tabs. A count repeats a move (5j) or picks a result (3Enter).\nPress ? or Esc to close.")
+	return lipgloss.NewStyle().Padding(1, 2).Render(lipgloss.JoinVertical(lipgloss.Left, append(rows, footer)...))
+}
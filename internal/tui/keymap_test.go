@@ -0,0 +1,90 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestRebind(t *testing.T) {
+	tests := []struct {
+		name    string
+		keys    map[string][]string
+		binding func(KeyMap) key.Binding
+		want    []string
+		help    string
+		err     string
+	}{
+		{"replace", map[string][]string{"down": {"n", "ctrl+n"}}, func(k KeyMap) key.Binding { return k.Down }, []string{"n", "ctrl+n"}, "n/Ctrl+n", ""},
+		{"prompt", map[string][]string{"editor": {"ctrl+x"}}, func(k KeyMap) key.Binding { return k.Editor }, []string{"ctrl+x"}, "Ctrl+x", ""},
+		{"untouched", map[string][]string{"down": {"n"}}, func(k KeyMap) key.Binding { return k.Up }, []string{"k", "up"}, "k/↑", ""},
+		{"disable", map[string][]string{"quit": {}}, func(k KeyMap) key.Binding { return k.Quit }, nil, "", ""},
+		{"unknown", map[string][]string{"jump": {"J"}}, nil, nil, "", `unknown key binding "jump"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := DefaultKeyMap()
+			err := k.Rebind(tt.keys)
+			if tt.err != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.err) {
+					t.Errorf("Rebind error = %v, want %s", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			b := tt.binding(k)
+			if tt.want == nil {
+				if b.Enabled() {
+					t.Errorf("binding still enabled: %q", b.Keys())
+				}
+				return
+			}
+			if !slices.Equal(b.Keys(), tt.want) || b.Help().Key != tt.help {
+				t.Errorf("keys %q, help %q, want %q, %q", b.Keys(), b.Help().Key, tt.want, tt.help)
+			}
+		})
+	}
+}
+
+func TestBindingNamesAreUnique(t *testing.T) {
+	k := DefaultKeyMap()
+	seen := map[string]bool{}
+	for _, g := range k.groups() {
+		if len(g.bindings) != len(g.names) {
+			t.Errorf("%s: %d bindings, %d names", g.title, len(g.bindings), len(g.names))
+		}
+		for _, n := range g.names {
+			if seen[n] {
+				t.Errorf("%s named twice", n)
+			}
+			seen[n] = true
+		}
+	}
+}
+
+func TestRenderHelp(t *testing.T) {
+	k := DefaultKeyMap()
+	if err := k.Rebind(map[string][]string{"open": {"O"}, "quit": {}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, width := range []int{40, 160} {
+		help := k.renderHelp(width)
+		for _, want := range []string{"O", "open in browser", "Query editing", "Alt+e"} {
+			if !strings.Contains(help, want) {
+				t.Errorf("help at width %d lacks %q", width, want)
+			}
+		}
+		if strings.Contains(help, "q/Ctrl+c") {
+			t.Errorf("help at width %d shows the disabled quit binding", width)
+		}
+	}
+	// Groups wrap to new rows rather than widening the overlay
+	if narrow, wide := lipgloss.Height(k.renderHelp(40)), lipgloss.Height(k.renderHelp(160)); narrow <= wide {
+		t.Errorf("narrow help is %d lines, wide %d", narrow, wide)
+	}
+}
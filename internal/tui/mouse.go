@@ -0,0 +1,63 @@
+package tui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// doubleClickTime is the longest gap between the clicks of a double click.
+const doubleClickTime = 400 * time.Millisecond
+
+// wheelStep is how many results one wheel notch scrolls.
+const wheelStep = 1
+
+// updateMouse handles the mouse in results mode: a click selects a result
+// and a double click opens it, the wheel scrolls, and the arrows of the
+// status bar page indicator change pages.
+func (m Model) updateMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	switch msg.Button {
+	case tea.MouseButtonWheelUp:
+		m.results.Scroll(-wheelStep)
+		return m, nil
+	case tea.MouseButtonWheelDown:
+		m.results.Scroll(wheelStep)
+		return m, nil
+	case tea.MouseButtonLeft:
+		if msg.Action != tea.MouseActionPress {
+			return m, nil
+		}
+	default:
+		return m, nil
+	}
+
+	top := lipgloss.Height(lipgloss.JoinVertical(lipgloss.Left, m.topSections()...))
+	status := top + lipgloss.Height(m.results.View())
+	if msg.Y == status {
+		// The status bar is padded by one column
+		switch m.results.PageAt(msg.X-statusBar.GetPaddingLeft(), m.backend.Name(), m.region) {
+		case -1:
+			cmd := m.prevPage()
+			return m, cmd
+		case 1:
+			cmd := m.nextPage()
+			return m, cmd
+		}
+		return m, nil
+	}
+
+	i, ok := m.results.ResultAt(msg.Y - top)
+	if !ok {
+		return m, nil
+	}
+	now := time.Now()
+	double := i == m.lastClickIdx && now.Sub(m.lastClick) < doubleClickTime
+	m.lastClick, m.lastClickIdx = now, i
+	m.results.SetCursor(i)
+	if double {
+		m.lastClick = time.Time{}
+		return m.cmdOpen("")
+	}
+	return m, nil
+}
@@ -0,0 +1,159 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/frort/ksk/internal/search"
+)
+
+// pickerMaxRows caps how many regions the picker lists at once.
+const pickerMaxRows = 12
+
+// regionPicker is a filterable list of the regions an engine supports. The
+// first entry clears the region.
+type regionPicker struct {
+	filter  textinput.Model
+	all     []search.Region
+	matches []search.Region
+	cursor  int
+}
+
+func newRegionPicker(current string, backend search.Backend) regionPicker {
+	ti := textinput.New()
+	ti.Prompt = "region> "
+	ti.PromptStyle = promptStyle
+	ti.Placeholder = "type to filter"
+	ti.Focus()
+
+	p := regionPicker{filter: ti, all: []search.Region{{Name: "any region"}}}
+	for _, r := range search.Regions() {
+		if supportsRegion(backend, r.Code) {
+			p.all = append(p.all, r)
+		}
+	}
+	p.refresh()
+	for i, r := range p.matches {
+		if r.Code == current {
+			p.cursor = i
+		}
+	}
+	return p
+}
+
+// refresh keeps the regions whose code or name contains the filter text.
+func (p *regionPicker) refresh() {
+	q := strings.ToLower(strings.TrimSpace(p.filter.Value()))
+	p.matches = p.matches[:0]
+	for _, r := range p.all {
+		if q == "" || strings.Contains(r.Code, q) || strings.Contains(strings.ToLower(r.Name), q) {
+			p.matches = append(p.matches, r)
+		}
+	}
+	p.cursor = min(p.cursor, max(0, len(p.matches)-1))
+}
+
+func (p *regionPicker) move(delta int) {
+	if len(p.matches) > 0 {
+		p.cursor = (p.cursor + delta + len(p.matches)) % len(p.matches)
+	}
+}
+
+// selected returns the highlighted region, if any match.
+func (p *regionPicker) selected() (search.Region, bool) {
+	if len(p.matches) == 0 {
+		return search.Region{}, false
+	}
+	return p.matches[p.cursor], true
+}
+
+func (p regionPicker) View() string {
+	lines := []string{p.filter.View()}
+	start := max(0, p.cursor-pickerMaxRows+1)
+	for i := start; i < len(p.matches) && i < start+pickerMaxRows; i++ {
+		r := p.matches[i]
+		label := r.Name
+		if r.Code != "" {
+			label = r.Code + "  " + r.Name
+		}
+		if i == p.cursor {
+			lines = append(lines, suggestionSelectedStyle.Render("> "+label))
+		} else {
+			lines = append(lines, suggestionStyle.Render("  "+label))
+		}
+	}
+	if len(p.matches) == 0 {
+		lines = append(lines, suggestionStyle.Render("  no matching region"))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m Model) updatePicker(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "esc", "ctrl+c":
+			m.picker = nil
+			return m, nil
+		case "down", "ctrl+n", "tab":
+			m.picker.move(1)
+			return m, nil
+		case "up", "ctrl+p", "shift+tab":
+			m.picker.move(-1)
+			return m, nil
+		case "enter":
+			r, ok := m.picker.selected()
+			m.picker = nil
+			if !ok {
+				return m, nil
+			}
+			return m.cmdRegion(r.Code)
+		}
+	}
+	var cmd tea.Cmd
+	m.picker.filter, cmd = m.picker.filter.Update(msg)
+	m.picker.refresh()
+	return m, cmd
+}
+
+// supportsRegion reports whether backend accepts the region code. Engines
+// that are not registered, or have no region list, accept any.
+func supportsRegion(backend search.Backend, code string) bool {
+	_, supported, err := search.EngineRegions(backend.Name())
+	return err != nil || len(supported) == 0 || slices.Contains(supported, code)
+}
+
+// cycleEngine switches to the next (delta 1) or previous (delta -1) engine
+// of Options.Engines and searches the current query there. Engines that
+// cannot be created, e.g. because they do not support the region, are
+// skipped.
+func (m Model) cycleEngine(delta int) (tea.Model, tea.Cmd) {
+	names := m.opts.Engines
+	if len(names) == 0 {
+		names = search.Engines()
+	}
+	i, steps := slices.Index(names, m.backend.Name()), len(names)-1
+	if i < 0 {
+		// Not a cycled engine: start from the first or last one
+		i, steps = -1, len(names)
+		if delta < 0 {
+			i = len(names)
+		}
+	}
+	var errs []string
+	for range steps {
+		i = (i + delta + len(names)) % len(names)
+		backend, err := m.newBackend(names[i])
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		m.backend = backend
+		return m.rerun()
+	}
+	if len(errs) > 0 {
+		m.errMsg = "no other engine: " + strings.Join(errs, "; ")
+	}
+	return m, nil
+}
@@ -2,34 +2,132 @@ package tui
 
 import (
 	"fmt"
+	"net/url"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/frort/ksk/internal/search"
 )
 
+// defaultSnippetLines is how many lines of snippet a result shows unless
+// configured otherwise.
+const defaultSnippetLines = 2
+
 type resultsModel struct {
 	results []search.Result
-	cursor  int
-	offset  int // viewport scroll offset
-	pageNum int
-	hasMore bool
-	width   int
-	height  int
+	answer  *search.Answer
+	// Spelling correction and related searches shown above the list
+	correction *search.Correction
+	related    []string
+	relatedIdx int // -1 when no suggestion is selected
+	// notes holds an optional badge per result, e.g. its rank elsewhere
+	notes []string
+	// hints holds the hint mode label per result, or nil outside hint mode
+	hints    []string
+	inactive bool // render the cursor unhighlighted
+	// list renders one line per result instead of bordered blocks
+	list bool
+	// snippetLines caps each snippet; expanded is the result whose snippet
+	// is shown in full, or -1
+	snippetLines int
+	expanded     int
+	cursor       int
+	offset       int // viewport scroll offset
+	pageNum      int
+	hasMore      bool
+	width        int
+	height       int
 }
 
-func newResultsModel() resultsModel {
-	return resultsModel{}
+func newResultsModel(opts Options) resultsModel {
+	snippetLines := opts.SnippetLines
+	if snippetLines <= 0 {
+		snippetLines = defaultSnippetLines
+	}
+	return resultsModel{relatedIdx: -1, snippetLines: snippetLines, expanded: -1, list: opts.ListLayout}
+}
+
+// SetList switches between one line per result and bordered blocks.
+func (m *resultsModel) SetList(list bool) {
+	m.list = list
+	m.ensureVisible()
 }
 
 func (m *resultsModel) SetResults(results []search.Result, pageNum int, hasMore bool) {
 	m.results = results
+	m.notes = nil
+	m.hints = nil
+	m.expanded = -1
 	m.cursor = 0
 	m.offset = 0
 	m.pageNum = pageNum
 	m.hasMore = hasMore
 }
 
+// SetNotes sets the badges shown before result titles, by index.
+func (m *resultsModel) SetNotes(notes []string) {
+	m.notes = notes
+}
+
+// ToggleExpanded shows the selected result's full snippet, or collapses it
+// again.
+func (m *resultsModel) ToggleExpanded() {
+	if len(m.results) == 0 {
+		return
+	}
+	if m.expanded == m.cursor {
+		m.expanded = -1
+	} else {
+		m.expanded = m.cursor
+	}
+	m.ensureVisible()
+}
+
+// SetHints sets the hint mode labels shown in place of result numbers, by
+// index. Nil shows the numbers again.
+func (m *resultsModel) SetHints(hints []string) {
+	m.hints = hints
+}
+
+func (m *resultsModel) SetAnswer(a *search.Answer) {
+	m.answer = a
+}
+
+func (m *resultsModel) Answer() *search.Answer {
+	return m.answer
+}
+
+func (m *resultsModel) SetSuggestions(c *search.Correction, related []string) {
+	m.correction = c
+	m.related = related
+	m.relatedIdx = -1
+}
+
+func (m *resultsModel) Correction() *search.Correction {
+	return m.correction
+}
+
+// NextRelated moves the related-search selection by delta, wrapping around.
+func (m *resultsModel) NextRelated(delta int) {
+	n := len(m.related)
+	if n == 0 {
+		return
+	}
+	if m.relatedIdx < 0 && delta < 0 {
+		m.relatedIdx = n - 1
+		return
+	}
+	m.relatedIdx = ((m.relatedIdx+delta)%n + n) % n
+}
+
+func (m *resultsModel) SelectedRelated() string {
+	if m.relatedIdx < 0 || m.relatedIdx >= len(m.related) {
+		return ""
+	}
+	return m.related[m.relatedIdx]
+}
+
 func (m *resultsModel) CursorDown() {
 	if m.cursor < len(m.results)-1 {
 		m.cursor++
@@ -56,6 +154,15 @@ func (m *resultsModel) CursorBottom() {
 	}
 }
 
+// SetCursor selects result i, clamped to the list.
+func (m *resultsModel) SetCursor(i int) {
+	if len(m.results) == 0 {
+		return
+	}
+	m.cursor = min(max(i, 0), len(m.results)-1)
+	m.ensureVisible()
+}
+
 func (m *resultsModel) SelectedResult() *search.Result {
 	if len(m.results) == 0 {
 		return nil
@@ -68,17 +175,60 @@ func (m *resultsModel) SetSize(w, h int) {
 	m.height = h
 }
 
+// listTop is the row of View where the result blocks start, below the
+// header.
+func (m *resultsModel) listTop() int {
+	header := m.renderHeader()
+	if header == "" {
+		return 0
+	}
+	return lipgloss.Height(header)
+}
+
+// listHeight is the viewport height left for result blocks below the header.
+func (m *resultsModel) listHeight() int {
+	return max(0, m.height-m.listTop())
+}
+
+// itemHeight is the number of rows result i takes in View, including the
+// margin below it. A list row is one line, plus the snippet when selected,
+// so it is counted without rendering.
+func (m *resultsModel) itemHeight(i int) int {
+	if m.list {
+		if i != m.cursor {
+			return 1
+		}
+		return 1 + len(m.rowSnippet(i))
+	}
+	return lipgloss.Height(m.renderBlock(i))
+}
+
+// itemMargin is the blank space at the bottom of each item.
+func (m *resultsModel) itemMargin() int {
+	if m.list {
+		return 0
+	}
+	return resultBlock.GetMarginBottom()
+}
+
+func (m *resultsModel) renderItem(i int) string {
+	if m.list {
+		return m.renderRow(i)
+	}
+	return m.renderBlock(i)
+}
+
 // visibleFrom counts how many results fit in the viewport starting from startIdx.
 func (m *resultsModel) visibleFrom(startIdx int) int {
 	if m.height <= 0 || len(m.results) == 0 {
 		return len(m.results)
 	}
+	height := m.listHeight()
 	totalHeight := 0
 	count := 0
 	for i := startIdx; i < len(m.results); i++ {
-		block := m.renderBlock(i)
-		blockH := lipgloss.Height(block) + 1
-		if totalHeight+blockH > m.height && count > 0 {
+		blockH := m.itemHeight(i)
+		if totalHeight+blockH > height && count > 0 {
 			break
 		}
 		totalHeight += blockH
@@ -87,6 +237,35 @@ func (m *resultsModel) visibleFrom(startIdx int) int {
 	return max(1, count)
 }
 
+// ResultAt returns the result whose block is drawn at row y of View. The
+// header and the margins between blocks hit nothing.
+func (m *resultsModel) ResultAt(y int) (int, bool) {
+	y -= m.listTop()
+	if y < 0 || len(m.results) == 0 {
+		return 0, false
+	}
+	row := 0
+	for i := m.offset; i < m.offset+m.visibleFrom(m.offset) && i < len(m.results); i++ {
+		blockH := m.itemHeight(i)
+		if y < row+blockH {
+			return i, y < row+blockH-m.itemMargin()
+		}
+		row += blockH
+	}
+	return 0, false
+}
+
+// Scroll moves the viewport by delta results, keeping the cursor on a
+// visible result.
+func (m *resultsModel) Scroll(delta int) {
+	if len(m.results) == 0 {
+		return
+	}
+	m.offset = min(max(m.offset+delta, 0), len(m.results)-1)
+	last := m.offset + m.visibleFrom(m.offset) - 1
+	m.cursor = min(max(m.cursor, m.offset), last)
+}
+
 func (m *resultsModel) ensureVisible() {
 	if m.cursor < m.offset {
 		m.offset = m.cursor
@@ -104,30 +283,57 @@ func (m *resultsModel) ensureVisible() {
 
 func (m *resultsModel) renderBlock(i int) string {
 	r := m.results[i]
-	selected := i == m.cursor
+	selected := i == m.cursor && !m.inactive
 
 	contentWidth := m.contentWidth()
 	// Text width = content width minus padding (1 left + 1 right)
 	textWidth := contentWidth - 2
 
-	title := truncate(r.Title, textWidth)
+	// The result number, or its hint label in hint mode, comes first
+	note := indexStyle.Render(fmt.Sprintf("%d.", i+1)) + " "
+	if i < len(m.hints) && m.hints[i] != "" {
+		note = hintStyle.Render(" "+m.hints[i]+" ") + " "
+	}
+	if i < len(m.notes) && m.notes[i] != "" {
+		note += noteStyle.Render(" "+m.notes[i]+" ") + " "
+	}
+	title := truncate(r.Title, textWidth-lipgloss.Width(note))
 	url := truncate(r.URL, textWidth)
-	snippet := truncate(r.Snippet, textWidth)
+	snippetLines := m.snippetLines
+	if i == m.expanded {
+		snippetLines = 0
+	}
+	snippet := strings.Join(wrapLines(r.Snippet, textWidth, snippetLines), "\n")
 
 	var titleRendered, urlRendered, snippetRendered string
-	if selected {
+	switch {
+	case r.IsAd():
+		title = truncate(r.Title, textWidth-lipgloss.Width(note)-lipgloss.Width(adBadgeText)-1)
+		style := adTitleStyle
+		if selected {
+			style = selectedAdTitleStyle
+		}
+		titleRendered = adBadge.Render(adBadgeText) + " " + style.Render(title)
+	case selected:
 		titleRendered = selectedTitleStyle.Render(title)
-	} else {
+	default:
 		titleRendered = titleStyle.Render(title)
 	}
-	urlRendered = urlStyle.Render(url)
-	snippetRendered = snippetStyle.Render(snippet)
+	titleRendered = note + titleRendered
+	if r.IsAd() {
+		urlRendered = adTextStyle.Render(url)
+		snippetRendered = adTextStyle.Render(snippet)
+	} else {
+		urlRendered = urlStyle.Render(url)
+		snippetRendered = snippetStyle.Render(snippet)
+	}
 
-	content := lipgloss.JoinVertical(lipgloss.Left,
-		titleRendered,
-		urlRendered,
-		snippetRendered,
-	)
+	lines := []string{titleRendered, urlRendered}
+	if len(r.Meta) > 0 {
+		lines = append(lines, metaStyle.Render(truncate(formatMeta(r.Meta), textWidth)))
+	}
+	lines = append(lines, snippetRendered)
+	content := lipgloss.JoinVertical(lipgloss.Left, lines...)
 
 	blockStyle := resultBlock.Width(contentWidth)
 	if selected {
@@ -136,6 +342,87 @@ func (m *resultsModel) renderBlock(i int) string {
 	return blockStyle.Render(content)
 }
 
+// renderRow renders result i as one line of number, title and domain. The
+// selected row adds its snippet below.
+func (m *resultsModel) renderRow(i int) string {
+	r := m.results[i]
+	selected := i == m.cursor && !m.inactive
+	width := m.contentWidth() + 2
+
+	marker := "  "
+	if selected {
+		marker = selectedTitleStyle.Render(">") + " "
+	}
+	prefix := marker + indexStyle.Render(fmt.Sprintf("%d.", i+1)) + " "
+	if i < len(m.hints) && m.hints[i] != "" {
+		prefix = marker + hintStyle.Render(" "+m.hints[i]+" ") + " "
+	}
+	if i < len(m.notes) && m.notes[i] != "" {
+		prefix += noteStyle.Render(" "+m.notes[i]+" ") + " "
+	}
+	if r.IsAd() {
+		prefix += adBadge.Render(adBadgeText) + " "
+	}
+
+	host := displayHost(r.URL)
+	room := width - lipgloss.Width(prefix)
+	hostW := min(ansi.StringWidth(host), room/3)
+	title := truncate(r.Title, room-hostW-2)
+
+	var titleRendered string
+	switch {
+	case r.IsAd() && selected:
+		titleRendered = selectedAdTitleStyle.Render(title)
+	case r.IsAd():
+		titleRendered = adTitleStyle.Render(title)
+	case selected:
+		titleRendered = selectedTitleStyle.Render(title)
+	default:
+		titleRendered = titleStyle.Render(title)
+	}
+	lines := []string{prefix + titleRendered + "  " + urlStyle.Render(truncate(host, hostW))}
+
+	indent := strings.Repeat(" ", lipgloss.Width(marker)+2)
+	for _, l := range m.rowSnippet(i) {
+		lines = append(lines, indent+snippetStyle.Render(l))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// rowSnippet wraps the snippet a list row shows under itself: only the
+// selected row has one.
+func (m *resultsModel) rowSnippet(i int) []string {
+	r := m.results[i]
+	if i != m.cursor || r.Snippet == "" {
+		return nil
+	}
+	snippetLines := m.snippetLines
+	if i == m.expanded {
+		snippetLines = 0
+	}
+	return wrapLines(r.Snippet, m.contentWidth()-2, snippetLines)
+}
+
+// displayHost returns the host of raw without "www.", or raw itself when it
+// does not parse.
+func displayHost(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+	return strings.TrimPrefix(u.Host, "www.")
+}
+
+// formatMeta joins site-specific fields into one line, e.g.
+// "Stars: 1.2k · Language: Go".
+func formatMeta(meta []search.Field) string {
+	parts := make([]string, len(meta))
+	for i, f := range meta {
+		parts[i] = f.Name + ": " + f.Value
+	}
+	return strings.Join(parts, " · ")
+}
+
 func (m *resultsModel) contentWidth() int {
 	// Account for border (2) + outer margin/space (2)
 	cw := m.width - 4
@@ -145,19 +432,130 @@ func (m *resultsModel) contentWidth() int {
 	return cw
 }
 
+// answerMaxLines caps the summary shown in the answer panel.
+const answerMaxLines = 3
+
+// answerMaxFacts caps the fact rows of the answer panel, so that a large
+// infobox cannot push the results off screen.
+const answerMaxFacts = 4
+
+func (m *resultsModel) renderAnswer() string {
+	a := m.answer
+	contentWidth := m.contentWidth()
+	textWidth := contentWidth - 2
+
+	lines := []string{answerTitleStyle.Render(truncate(a.Title, textWidth))}
+	if a.Summary != "" {
+		for _, l := range wrapLines(a.Summary, textWidth, answerMaxLines) {
+			lines = append(lines, snippetStyle.Render(l))
+		}
+	}
+	for _, f := range a.Facts[:min(len(a.Facts), answerMaxFacts)] {
+		lines = append(lines, answerFactStyle.Render(f.Name+": ")+snippetStyle.Render(truncate(f.Value, textWidth-ansi.StringWidth(f.Name)-2)))
+	}
+	if n := len(a.Facts) - answerMaxFacts; n > 0 {
+		lines = append(lines, indexStyle.Render(fmt.Sprintf("+%d more", n)))
+	}
+	if a.URL != "" {
+		lines = append(lines, urlStyle.Render(truncate(a.URL, textWidth)))
+	}
+
+	return answerBlock.Width(contentWidth).Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
+
+// renderHeader renders the answer panel, correction notice and related
+// searches, or "" when there are none.
+func (m *resultsModel) renderHeader() string {
+	var parts []string
+	if m.answer != nil {
+		parts = append(parts, m.renderAnswer())
+	}
+	var notes []string
+	if m.correction != nil {
+		notes = append(notes, m.renderCorrection())
+	}
+	if len(m.related) > 0 {
+		notes = append(notes, m.renderRelated())
+	}
+	if len(notes) > 0 {
+		parts = append(parts, noticeStyle.Render(lipgloss.JoinVertical(lipgloss.Left, notes...)))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, parts...)
+}
+
+func (m *resultsModel) renderCorrection() string {
+	c := m.correction
+	if c.Applied && c.Original == "" {
+		return noticeTextStyle.Render("Showing results for ") + correctionStyle.Render(c.Query)
+	}
+	if c.Applied {
+		return noticeTextStyle.Render("Showing results for ") + correctionStyle.Render(c.Query) +
+			noticeTextStyle.Render(" — search instead for ") + correctionStyle.Render(c.Original) +
+			noticeTextStyle.Render(" (c)")
+	}
+	return noticeTextStyle.Render("Did you mean ") + correctionStyle.Render(c.Query) +
+		noticeTextStyle.Render("? (c)")
+}
+
+// renderRelated renders the related searches as a single row, scrolled so
+// that the selected suggestion stays visible.
+func (m *resultsModel) renderRelated() string {
+	const label = "Related: "
+	width := m.contentWidth() - len(label)
+
+	start := 0
+	if m.relatedIdx > 0 {
+		for start < m.relatedIdx && relatedRowWidth(m.related[start:m.relatedIdx+1]) > width {
+			start++
+		}
+	}
+
+	var b strings.Builder
+	used := 0
+	for i := start; i < len(m.related); i++ {
+		chip := " " + m.related[i] + " "
+		w := lipgloss.Width(chip) + 1
+		if used+w > width && used > 0 {
+			break
+		}
+		if i == m.relatedIdx {
+			b.WriteString(relatedSelectedStyle.Render(chip))
+		} else {
+			b.WriteString(relatedStyle.Render(chip))
+		}
+		b.WriteString(" ")
+		used += w
+	}
+	return noticeTextStyle.Render(label) + b.String()
+}
+
+func relatedRowWidth(related []string) int {
+	w := 0
+	for _, r := range related {
+		w += lipgloss.Width(r) + 3
+	}
+	return w
+}
+
 func (m *resultsModel) View() string {
-	if len(m.results) == 0 {
+	if len(m.results) == 0 && m.renderHeader() == "" {
 		return "\n  No results found.\n"
 	}
 
 	var b strings.Builder
 	totalHeight := 0
+	height := m.listHeight()
+
+	if header := m.renderHeader(); header != "" {
+		b.WriteString(header)
+		b.WriteString("\n")
+	}
 
 	for i := m.offset; i < len(m.results); i++ {
-		block := m.renderBlock(i)
-		blockH := lipgloss.Height(block) + 1 // +1 for separator newline
+		block := m.renderItem(i)
+		blockH := lipgloss.Height(block)
 
-		if totalHeight+blockH > m.height && totalHeight > 0 {
+		if totalHeight+blockH > height && totalHeight > 0 {
 			break
 		}
 
@@ -169,24 +567,76 @@ func (m *resultsModel) View() string {
 	return b.String()
 }
 
-func (m *resultsModel) StatusView(engineName string) string {
+func (m *resultsModel) StatusView(engineName, region string) string {
 	if len(m.results) == 0 {
 		return ""
 	}
-	return fmt.Sprintf("[%s] Page %d | %d/%d | j/k:move h/l:page Enter:open /:search q:quit",
-		engineName, m.pageNum, m.cursor+1, len(m.results))
+	before, indicator := m.pageIndicator(engineName, region)
+	return fmt.Sprintf("%s%s | %d/%d", before, indicator, m.cursor+1, len(m.results))
 }
 
+// pageIndicator returns the status text that precedes the page indicator
+// and the indicator itself, e.g. "[brave] " and "‹ Page 2 ›". The arrows are
+// only drawn when there is a page that way.
+func (m *resultsModel) pageIndicator(engineName, region string) (before, indicator string) {
+	if region != "" {
+		engineName += " · " + region
+	}
+	prev, next := " ", " "
+	if m.pageNum > 1 {
+		prev = "‹"
+	}
+	if m.hasMore {
+		next = "›"
+	}
+	return "[" + engineName + "] ", fmt.Sprintf("%s Page %d %s", prev, m.pageNum, next)
+}
+
+// PageAt maps column x of StatusView to the page arrow there: -1 for the
+// previous page, 1 for the next, 0 for anything else.
+func (m *resultsModel) PageAt(x int, engineName, region string) int {
+	before, indicator := m.pageIndicator(engineName, region)
+	x -= lipgloss.Width(before)
+	switch {
+	case x >= 0 && x <= 1 && m.pageNum > 1:
+		return -1
+	case x >= lipgloss.Width(indicator)-2 && x < lipgloss.Width(indicator) && m.hasMore:
+		return 1
+	}
+	return 0
+}
+
+// truncate shortens s to maxWidth terminal cells, ending in "..." when it
+// was cut. Wide characters such as CJK and emoji count as two cells.
 func truncate(s string, maxWidth int) string {
 	if maxWidth <= 0 {
 		return s
 	}
-	runes := []rune(s)
-	if len(runes) > maxWidth {
-		if maxWidth > 3 {
-			return string(runes[:maxWidth-3]) + "..."
+	if maxWidth <= 3 {
+		return ansi.Truncate(s, maxWidth, "")
+	}
+	return ansi.Truncate(s, maxWidth, "...")
+}
+
+// wrapLines word-wraps s to width cells, breaking words that do not fit a
+// line, and keeps at most maxLines lines (0 keeps all). The last kept line
+// ends in "..." when text was dropped.
+func wrapLines(s string, width, maxLines int) []string {
+	s = strings.Join(strings.Fields(s), " ")
+	if width <= 0 {
+		return []string{s}
+	}
+	lines := strings.Split(ansi.Wrap(s, width, ""), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(l, " ")
+	}
+	if maxLines > 0 && len(lines) > maxLines {
+		lines = lines[:maxLines]
+		last := lines[maxLines-1]
+		if ansi.StringWidth(last)+3 > width {
+			last = ansi.Truncate(last, width-3, "")
 		}
-		return string(runes[:maxWidth])
+		lines[maxLines-1] = last + "..."
 	}
-	return s
+	return lines
 }
@@ -0,0 +1,198 @@
+package tui
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/frort/ksk/internal/search"
+)
+
+func threeResults(list bool) resultsModel {
+	m := newResultsModel(Options{ListLayout: list})
+	m.SetSize(60, 100)
+	m.SetResults([]search.Result{
+		{Title: "Go", URL: "https://go.dev/", Snippet: "The Go programming language"},
+		{Title: "Go Tour", URL: "https://go.dev/tour/", Snippet: "A tour of Go"},
+		{Title: "Go Blog", URL: "https://go.dev/blog/", Snippet: "The Go blog"},
+	}, 1, false)
+	return m
+}
+
+func TestResultAt(t *testing.T) {
+	tests := []struct {
+		name   string
+		list   bool
+		offset int
+		header bool
+		y      int
+		want   int
+		hit    bool
+	}{
+		// A block is a border, title, URL, snippet and border, then a margin
+		{"first block top", false, 0, false, 0, 0, true},
+		{"first block bottom", false, 0, false, 4, 0, true},
+		{"margin", false, 0, false, 5, 0, false},
+		{"second block", false, 0, false, 6, 1, true},
+		{"last margin", false, 0, false, 17, 2, false},
+		{"below the blocks", false, 0, false, 18, 0, false},
+		{"above", false, 0, false, -1, 0, false},
+		{"scrolled", false, 1, false, 0, 1, true},
+		{"header", false, 0, true, 0, 0, false},
+		// The selected row has its snippet below it
+		{"selected row", true, 0, false, 0, 0, true},
+		{"selected snippet", true, 0, false, 1, 0, true},
+		{"row", true, 0, false, 2, 1, true},
+		{"last row", true, 0, false, 3, 2, true},
+		{"below the rows", true, 0, false, 4, 0, false},
+		{"header row", true, 0, true, 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := threeResults(tt.list)
+			m.offset = tt.offset
+			if tt.header {
+				m.SetSuggestions(&search.Correction{Query: "golang"}, nil)
+			}
+			got, hit := m.ResultAt(tt.y)
+			if got != tt.want || hit != tt.hit {
+				t.Errorf("ResultAt(%d) = %d, %v, want %d, %v", tt.y, got, hit, tt.want, tt.hit)
+			}
+			if tt.header {
+				if got, hit := m.ResultAt(m.listTop()); got != 0 || !hit {
+					t.Errorf("ResultAt below the header = %d, %v", got, hit)
+				}
+			}
+		})
+	}
+}
+
+func TestPageAt(t *testing.T) {
+	// "[stub] ‹ Page 2 › | 1/3": the arrows are columns 7 and 16
+	tests := []struct {
+		name    string
+		pageNum int
+		hasMore bool
+		region  string
+		x       int
+		want    int
+	}{
+		{"previous", 2, true, "", 7, -1},
+		{"space after previous", 2, true, "", 8, -1},
+		{"page number", 2, true, "", 12, 0},
+		{"next", 2, true, "", 16, 1},
+		{"engine", 2, true, "", 2, 0},
+		{"past the indicator", 2, true, "", 17, 0},
+		{"no previous page", 1, true, "", 7, 0},
+		{"no next page", 2, false, "", 16, 0},
+		{"region", 2, true, "jp", 12, -1},
+		{"region next", 2, true, "jp", 21, 1},
+	}
+	for _, tt := range tests {
+		m := threeResults(false)
+		m.pageNum, m.hasMore = tt.pageNum, tt.hasMore
+		if got := m.PageAt(tt.x, "stub", tt.region); got != tt.want {
+			t.Errorf("%s: PageAt(%d) on %q = %d, want %d", tt.name, tt.x, m.StatusView("stub", tt.region), got, tt.want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+		want  string
+	}{
+		{"golang", 10, "golang"},
+		{"golang", 6, "golang"},
+		{"golang", 5, "go..."},
+		{"golang", 3, "gol"},
+		{"golang", 0, "golang"},
+		// Wide characters take two cells and are never split
+		{"日本語の検索", 12, "日本語の検索"},
+		{"日本語の検索", 10, "日本語..."},
+		{"日本語の検索", 8, "日本..."},
+		{"日本語の検索", 3, "日"},
+		{"Go 🚀 fast", 8, "Go 🚀..."},
+		{"Go 🚀 fast", 7, "Go ..."},
+	}
+	for _, tt := range tests {
+		if got := truncate(tt.s, tt.width); got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.s, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestWrapLines(t *testing.T) {
+	tests := []struct {
+		s               string
+		width, maxLines int
+		want            []string
+	}{
+		{"the go programming language", 12, 0, []string{"the go", "programming", "language"}},
+		{"the go programming language", 12, 2, []string{"the go", "programmi..."}},
+		{"  spaced\n out  ", 20, 0, []string{"spaced out"}},
+		{"short", 20, 1, []string{"short"}},
+		{"no width", 0, 1, []string{"no width"}},
+		// Wide characters wrap by cells, not runes
+		{"日本語の検索エンジン", 8, 0, []string{"日本語の", "検索エン", "ジン"}},
+		{"日本語の検索エンジン", 8, 2, []string{"日本語の", "検索..."}},
+		{"東京 タワー", 5, 0, []string{"東京", "タワ", "ー"}},
+	}
+	for _, tt := range tests {
+		got := wrapLines(tt.s, tt.width, tt.maxLines)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("wrapLines(%q, %d, %d) = %q, want %q", tt.s, tt.width, tt.maxLines, got, tt.want)
+		}
+		for _, l := range got {
+			if tt.width > 0 && ansi.StringWidth(l) > tt.width {
+				t.Errorf("wrapLines(%q, %d): %q is wider", tt.s, tt.width, l)
+			}
+		}
+	}
+}
+
+func TestListItemHeight(t *testing.T) {
+	long := "Go is an open source programming language that makes it simple to build secure, scalable systems."
+	tests := []struct {
+		name     string
+		snippet  string
+		cursor   int
+		expanded bool
+		want     int
+	}{
+		{"row", long, 1, false, 1},
+		{"selected", "A tour of Go", 0, false, 2},
+		{"selected without snippet", "", 0, false, 1},
+		// Snippets are cut to two lines unless expanded
+		{"selected long", long, 0, false, 3},
+		{"expanded", long, 0, true, 4},
+	}
+	for _, tt := range tests {
+		m := threeResults(true)
+		m.SetSize(40, 100)
+		m.results[0].Snippet = tt.snippet
+		m.SetCursor(tt.cursor)
+		if tt.expanded {
+			m.ToggleExpanded()
+		}
+		if got := m.itemHeight(0); got != tt.want {
+			t.Errorf("%s: itemHeight = %d, want %d", tt.name, got, tt.want)
+		}
+		if got := lipgloss.Height(m.renderItem(0)); got != tt.want {
+			t.Errorf("%s: rendered %d lines, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestListVisibleFrom(t *testing.T) {
+	m := threeResults(true)
+	for _, tt := range []struct{ height, want int }{{4, 3}, {3, 2}, {1, 1}} {
+		m.SetSize(60, tt.height)
+		if got := m.visibleFrom(0); got != tt.want {
+			t.Errorf("visibleFrom in %d lines = %d, want %d", tt.height, got, tt.want)
+		}
+	}
+}
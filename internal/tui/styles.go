@@ -1,64 +1,378 @@
 package tui
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"fmt"
+	"slices"
+	"strings"
 
+	"github.com/charmbracelet/lipgloss"
+)
+
+const adBadgeText = " Ad "
+
+// Theme is the palette the TUI is drawn with.
+type Theme struct {
+	Primary   lipgloss.TerminalColor // titles, prompt, spinner
+	Highlight lipgloss.TerminalColor // selection and badges
+	Muted     lipgloss.TerminalColor
+	URL       lipgloss.TerminalColor
+	Snippet   lipgloss.TerminalColor
+	Error     lipgloss.TerminalColor
+	Border    lipgloss.TerminalColor
+	ActiveBg  lipgloss.TerminalColor // behind the selected result
+	// Reverse marks the selection and badges with reverse video, for
+	// palettes without colors.
+	Reverse bool
+}
+
+// Built-in themes. Each color names its 256- and 16-color fallbacks so
+// that limited terminals get a chosen match instead of the nearest one.
 var (
-	// Colors
-	colorPrimary   = lipgloss.Color("#5faf5f")
-	colorHighlight = lipgloss.Color("#ffaf5f")
-	colorMuted     = lipgloss.Color("#666666")
-	colorURL       = lipgloss.Color("#888888")
-	colorSnippet   = lipgloss.Color("#aaaaaa")
-	colorError     = lipgloss.Color("#ff5f5f")
-	colorBorder    = lipgloss.Color("#3a4a3a")
-	colorActiveBg  = lipgloss.Color("#0a1a0a")
+	darkTheme = Theme{
+		Primary:   lipgloss.CompleteColor{TrueColor: "#5faf5f", ANSI256: "71", ANSI: "2"},
+		Highlight: lipgloss.CompleteColor{TrueColor: "#ffaf5f", ANSI256: "215", ANSI: "11"},
+		Muted:     lipgloss.CompleteColor{TrueColor: "#666666", ANSI256: "241", ANSI: "8"},
+		URL:       lipgloss.CompleteColor{TrueColor: "#888888", ANSI256: "245", ANSI: "8"},
+		Snippet:   lipgloss.CompleteColor{TrueColor: "#aaaaaa", ANSI256: "248", ANSI: "7"},
+		Error:     lipgloss.CompleteColor{TrueColor: "#ff5f5f", ANSI256: "203", ANSI: "9"},
+		Border:    lipgloss.CompleteColor{TrueColor: "#3a4a3a", ANSI256: "238", ANSI: "8"},
+		ActiveBg:  lipgloss.CompleteColor{TrueColor: "#0a1a0a", ANSI256: "233", ANSI: "0"},
+	}
+
+	lightTheme = Theme{
+		Primary:   lipgloss.CompleteColor{TrueColor: "#2e7d32", ANSI256: "28", ANSI: "2"},
+		Highlight: lipgloss.CompleteColor{TrueColor: "#c55a00", ANSI256: "166", ANSI: "3"},
+		Muted:     lipgloss.CompleteColor{TrueColor: "#8a8a8a", ANSI256: "245", ANSI: "8"},
+		URL:       lipgloss.CompleteColor{TrueColor: "#5f5f5f", ANSI256: "59", ANSI: "8"},
+		Snippet:   lipgloss.CompleteColor{TrueColor: "#3a3a3a", ANSI256: "237", ANSI: "0"},
+		Error:     lipgloss.CompleteColor{TrueColor: "#c62828", ANSI256: "160", ANSI: "1"},
+		Border:    lipgloss.CompleteColor{TrueColor: "#b8c8b8", ANSI256: "250", ANSI: "7"},
+		ActiveBg:  lipgloss.CompleteColor{TrueColor: "#eef6ee", ANSI256: "255", ANSI: "15"},
+	}
+
+	highContrastTheme = Theme{
+		Primary:   lipgloss.CompleteColor{TrueColor: "#00ff5f", ANSI256: "47", ANSI: "10"},
+		Highlight: lipgloss.CompleteColor{TrueColor: "#ffff00", ANSI256: "226", ANSI: "11"},
+		Muted:     lipgloss.CompleteColor{TrueColor: "#d0d0d0", ANSI256: "252", ANSI: "7"},
+		URL:       lipgloss.CompleteColor{TrueColor: "#ffffff", ANSI256: "15", ANSI: "15"},
+		Snippet:   lipgloss.CompleteColor{TrueColor: "#ffffff", ANSI256: "15", ANSI: "15"},
+		Error:     lipgloss.CompleteColor{TrueColor: "#ff0000", ANSI256: "196", ANSI: "9"},
+		Border:    lipgloss.CompleteColor{TrueColor: "#ffffff", ANSI256: "15", ANSI: "15"},
+		ActiveBg:  lipgloss.CompleteColor{TrueColor: "#000000", ANSI256: "16", ANSI: "0"},
+	}
+
+	monoTheme = Theme{
+		Primary:   lipgloss.NoColor{},
+		Highlight: lipgloss.NoColor{},
+		Muted:     lipgloss.NoColor{},
+		URL:       lipgloss.NoColor{},
+		Snippet:   lipgloss.NoColor{},
+		Error:     lipgloss.NoColor{},
+		Border:    lipgloss.NoColor{},
+		ActiveBg:  lipgloss.NoColor{},
+		Reverse:   true,
+	}
+)
+
+// themes holds the built-in themes by name. "auto" follows the terminal
+// background, picking dark or light.
+var themes = map[string]Theme{
+	"auto":          adaptiveTheme(lightTheme, darkTheme),
+	"dark":          darkTheme,
+	"light":         lightTheme,
+	"high-contrast": highContrastTheme,
+	"mono":          monoTheme,
+}
 
+// adaptiveTheme combines a theme for light backgrounds with one for dark
+// backgrounds.
+func adaptiveTheme(light, dark Theme) Theme {
+	pick := func(l, d lipgloss.TerminalColor) lipgloss.TerminalColor {
+		return lipgloss.CompleteAdaptiveColor{Light: l.(lipgloss.CompleteColor), Dark: d.(lipgloss.CompleteColor)}
+	}
+	return Theme{
+		Primary:   pick(light.Primary, dark.Primary),
+		Highlight: pick(light.Highlight, dark.Highlight),
+		Muted:     pick(light.Muted, dark.Muted),
+		URL:       pick(light.URL, dark.URL),
+		Snippet:   pick(light.Snippet, dark.Snippet),
+		Error:     pick(light.Error, dark.Error),
+		Border:    pick(light.Border, dark.Border),
+		ActiveBg:  pick(light.ActiveBg, dark.ActiveBg),
+	}
+}
+
+// ThemeNames lists the built-in themes.
+func ThemeNames() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// LookupTheme returns the built-in theme called name.
+func LookupTheme(name string) (Theme, error) {
+	t, ok := themes[name]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown theme %q (use %s)", name, strings.Join(ThemeNames(), ", "))
+	}
+	return t, nil
+}
+
+var (
 	// Result block styles
+	resultBlock   lipgloss.Style
+	selectedBlock lipgloss.Style
+
+	// Instant answer panel
+	answerBlock      lipgloss.Style
+	answerTitleStyle lipgloss.Style
+	answerFactStyle  lipgloss.Style
+
+	// Spelling correction and related searches
+	noticeStyle          lipgloss.Style
+	noticeTextStyle      lipgloss.Style
+	correctionStyle      lipgloss.Style
+	relatedStyle         lipgloss.Style
+	relatedSelectedStyle lipgloss.Style
+
+	titleStyle         lipgloss.Style
+	selectedTitleStyle lipgloss.Style
+	urlStyle           lipgloss.Style
+	snippetStyle       lipgloss.Style
+	metaStyle          lipgloss.Style
+
+	// Sponsored results
+	adBadge              lipgloss.Style
+	adTitleStyle         lipgloss.Style
+	selectedAdTitleStyle lipgloss.Style
+	adTextStyle          lipgloss.Style
+
+	// Result numbers and hint mode labels
+	indexStyle lipgloss.Style
+	hintStyle  lipgloss.Style
+
+	// Per-result badge, e.g. the rank in another engine in compare mode
+	noteStyle lipgloss.Style
+
+	// Compare mode column headers
+	compareHeaderStyle        lipgloss.Style
+	compareHeaderFocusedStyle lipgloss.Style
+
+	// Tab bar
+	tabStyle       lipgloss.Style
+	tabActiveStyle lipgloss.Style
+	tabDoneStyle   lipgloss.Style
+
+	// Status bar
+	statusBar lipgloss.Style
+
+	// Prompt
+	promptStyle lipgloss.Style
+
+	// Autocomplete dropdown
+	suggestionStyle         lipgloss.Style
+	suggestionSelectedStyle lipgloss.Style
+
+	// Help overlay and status bar key hints
+	helpTitleStyle lipgloss.Style
+	helpKeyStyle   lipgloss.Style
+	helpDescStyle  lipgloss.Style
+
+	// Error
+	errorStyle lipgloss.Style
+
+	// Spinner
+	spinnerStyle lipgloss.Style
+)
+
+func init() {
+	SetTheme(themes["auto"], false)
+}
+
+// SetTheme restyles the TUI with t. Compact drops the rounded result
+// borders and the gaps between results so that more fit on screen. Call it
+// before creating a Model.
+func SetTheme(t Theme, compact bool) {
 	resultBlock = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(colorBorder).
-			Padding(0, 1).
-			MarginBottom(1)
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.Border).
+		Padding(0, 1).
+		MarginBottom(1)
 
 	selectedBlock = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(colorHighlight).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.Highlight).
+		Padding(0, 1).
+		MarginBottom(1).
+		Background(t.ActiveBg)
+
+	if compact {
+		// A bar on the left still marks the selection
+		resultBlock = lipgloss.NewStyle().
+			Border(lipgloss.NormalBorder(), false, false, false, true).
+			BorderForeground(t.Border).
+			Padding(0, 1)
+
+		selectedBlock = lipgloss.NewStyle().
+			Border(lipgloss.ThickBorder(), false, false, false, true).
+			BorderForeground(t.Highlight).
 			Padding(0, 1).
-			MarginBottom(1).
-			Background(colorActiveBg)
+			Background(t.ActiveBg)
+	}
+
+	answerBlock = lipgloss.NewStyle().
+		Border(lipgloss.DoubleBorder()).
+		BorderForeground(t.Primary).
+		Padding(0, 1).
+		MarginBottom(1)
+
+	answerTitleStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(t.Primary).
+		Underline(true)
+
+	answerFactStyle = lipgloss.NewStyle().
+		Foreground(t.Highlight)
+
+	noticeStyle = lipgloss.NewStyle().
+		Padding(0, 1).
+		MarginBottom(1)
+
+	noticeTextStyle = lipgloss.NewStyle().
+		Foreground(t.Muted)
+
+	correctionStyle = lipgloss.NewStyle().
+		Foreground(t.Highlight).
+		Bold(true)
+
+	relatedStyle = lipgloss.NewStyle().
+		Foreground(t.Snippet).
+		Background(t.Border)
+
+	relatedSelectedStyle = lipgloss.NewStyle().
+		Foreground(t.ActiveBg).
+		Background(t.Highlight).
+		Bold(true).
+		Reverse(t.Reverse)
 
 	titleStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(colorPrimary)
+		Bold(true).
+		Foreground(t.Primary)
 
 	selectedTitleStyle = lipgloss.NewStyle().
-				Bold(true).
-				Foreground(colorHighlight)
+		Bold(true).
+		Foreground(t.Highlight).
+		Reverse(t.Reverse)
 
 	urlStyle = lipgloss.NewStyle().
-			Foreground(colorURL).
-			Italic(true)
+		Foreground(t.URL).
+		Italic(true)
 
 	snippetStyle = lipgloss.NewStyle().
-			Foreground(colorSnippet)
+		Foreground(t.Snippet)
+
+	metaStyle = lipgloss.NewStyle().
+		Foreground(t.Highlight).
+		Faint(true)
+
+	adBadge = lipgloss.NewStyle().
+		Foreground(t.ActiveBg).
+		Background(t.Muted).
+		Bold(true).
+		Reverse(t.Reverse)
+
+	adTitleStyle = lipgloss.NewStyle().
+		Foreground(t.Muted)
+
+	// The selection highlight, dimmed so that the ad still reads as one
+	selectedAdTitleStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(t.Highlight).
+		Faint(true).
+		Reverse(t.Reverse)
+
+	adTextStyle = lipgloss.NewStyle().
+		Foreground(t.Muted).
+		Faint(true)
+
+	indexStyle = lipgloss.NewStyle().
+		Foreground(t.Muted)
+
+	hintStyle = lipgloss.NewStyle().
+		Foreground(t.ActiveBg).
+		Background(t.Primary).
+		Bold(true).
+		Reverse(t.Reverse)
+
+	noteStyle = lipgloss.NewStyle().
+		Foreground(t.ActiveBg).
+		Background(t.Highlight).
+		Bold(true).
+		Reverse(t.Reverse)
+
+	compareHeaderStyle = lipgloss.NewStyle().
+		Foreground(t.Muted).
+		Bold(true).
+		Padding(0, 1)
+
+	compareHeaderFocusedStyle = lipgloss.NewStyle().
+		Foreground(t.Primary).
+		Bold(true).
+		Underline(true).
+		Padding(0, 1)
+
+	tabStyle = lipgloss.NewStyle().
+		Foreground(t.Snippet).
+		Background(t.Border)
+
+	tabActiveStyle = lipgloss.NewStyle().
+		Foreground(t.ActiveBg).
+		Background(t.Primary).
+		Bold(true).
+		Reverse(t.Reverse)
+
+	tabDoneStyle = lipgloss.NewStyle().
+		Foreground(t.Highlight).
+		Background(t.Border).
+		Bold(true)
 
-	// Status bar
 	statusBar = lipgloss.NewStyle().
-			Foreground(colorMuted).
-			Padding(0, 1)
+		Foreground(t.Muted).
+		Padding(0, 1)
 
-	// Prompt
 	promptStyle = lipgloss.NewStyle().
-			Foreground(colorPrimary).
-			Bold(true)
+		Foreground(t.Primary).
+		Bold(true)
+
+	suggestionStyle = lipgloss.NewStyle().
+		Foreground(t.Snippet).
+		PaddingLeft(2)
+
+	suggestionSelectedStyle = lipgloss.NewStyle().
+		Foreground(t.Highlight).
+		Bold(true).
+		PaddingLeft(2)
+
+	helpTitleStyle = lipgloss.NewStyle().
+		Foreground(t.Highlight).
+		Bold(true).
+		Underline(true)
+
+	helpKeyStyle = lipgloss.NewStyle().
+		Foreground(t.Primary).
+		Bold(true)
+
+	helpDescStyle = lipgloss.NewStyle().
+		Foreground(t.Snippet)
 
-	// Error
 	errorStyle = lipgloss.NewStyle().
-			Foreground(colorError).
-			Bold(true).
-			Padding(1, 2)
+		Foreground(t.Error).
+		Bold(true).
+		Padding(1, 2)
 
-	// Spinner
 	spinnerStyle = lipgloss.NewStyle().
-			Foreground(colorPrimary)
-)
+		Foreground(t.Primary)
+}
@@ -0,0 +1,54 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLookupTheme(t *testing.T) {
+	tests := []struct {
+		name    string
+		reverse bool
+		err     string
+	}{
+		{"auto", false, ""},
+		{"dark", false, ""},
+		{"high-contrast", false, ""},
+		{"mono", true, ""},
+		{"Dark", false, `unknown theme "Dark" (use auto, dark, high-contrast, light, mono)`},
+		{"", false, `unknown theme ""`},
+	}
+	for _, tt := range tests {
+		theme, err := LookupTheme(tt.name)
+		switch {
+		case tt.err != "":
+			if err == nil || !strings.HasPrefix(err.Error(), tt.err) {
+				t.Errorf("LookupTheme(%q) error = %v, want %s", tt.name, err, tt.err)
+			}
+		case err != nil || theme.Primary == nil || theme.Reverse != tt.reverse:
+			t.Errorf("LookupTheme(%q) = %+v, %v", tt.name, theme, err)
+		}
+	}
+}
+
+func TestCompactBlockHeight(t *testing.T) {
+	t.Cleanup(func() { SetTheme(themes["auto"], false) })
+	for _, tt := range []struct {
+		compact bool
+		height  int
+		margin  int
+	}{
+		// Borders above and below the title, URL and snippet, then a gap
+		{false, 6, 1},
+		{true, 3, 0},
+	} {
+		SetTheme(themes["dark"], tt.compact)
+		m := threeResults(false)
+		if h, margin := m.itemHeight(1), m.itemMargin(); h != tt.height || margin != tt.margin {
+			t.Errorf("compact %v: block height %d, margin %d, want %d, %d", tt.compact, h, margin, tt.height, tt.margin)
+		}
+		if i, hit := m.ResultAt(tt.height); i != 1 || !hit {
+			t.Errorf("compact %v: ResultAt(%d) = %d, %v, want the second block", tt.compact, tt.height, i, hit)
+		}
+	}
+}
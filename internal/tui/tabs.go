@@ -0,0 +1,225 @@
+package tui
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/frort/ksk/internal/search"
+	"github.com/frort/ksk/internal/session"
+)
+
+// maxTabLabel caps the query shown in a tab bar label.
+const maxTabLabel = 20
+
+// tab is the state of one search: its own query, engine, results and mode.
+type tab struct {
+	id      int // identifies the tab in messages from its searches
+	state   state
+	input   inputModel
+	results resultsModel
+	query   string
+	page    *search.Page
+	pages   map[int]*search.Page // pages loaded for query, by number
+	errMsg  string
+	filter  string // shows only results matching every word
+	backend search.Backend
+	// region and safe are the settings backend was created with
+	region string
+	safe   search.SafeSearch
+	// done marks a search that finished while the tab was in the background
+	done bool
+}
+
+func newTab(id int, backend search.Backend, opts Options) tab {
+	return tab{
+		id:      id,
+		state:   stateInput,
+		input:   newInputModel("search the web...", opts),
+		results: newResultsModel(opts),
+		backend: backend,
+		region:  opts.Region,
+		safe:    opts.SafeSearch,
+	}
+}
+
+func (t *tab) cachePage(p *search.Page) {
+	if t.pages == nil {
+		t.pages = make(map[int]*search.Page)
+	}
+	t.pages[p.PageNum] = p
+}
+
+// showPage displays a loaded page.
+func (t *tab) showPage(p *search.Page, showAds bool) {
+	t.page = p
+	t.results.SetResults(filterResults(filterAds(p.Results, showAds), t.filter), p.PageNum, p.HasMore)
+	t.results.SetAnswer(p.Answer)
+	t.results.SetSuggestions(p.Correction, p.Related)
+}
+
+// restore shows the saved state of a tab.
+func (t *tab) restore(s session.Tab, showAds bool) {
+	t.query = s.Query
+	t.filter = s.Filter
+	t.input.SetValue(s.Query)
+	for _, p := range s.Pages {
+		t.cachePage(p)
+	}
+	if p := s.Page(s.PageNum); p != nil {
+		t.showPage(p, showAds)
+		t.results.SetCursor(s.Cursor)
+		t.state = stateResults
+		t.input.Blur()
+	}
+}
+
+// snapshot captures the state of the tab to save in a session.
+func (t tab) snapshot() session.Tab {
+	s := session.Tab{
+		Query:      t.query,
+		Engine:     t.backend.Name(),
+		Region:     t.region,
+		SafeSearch: t.safe,
+		Cursor:     t.results.cursor,
+		Filter:     t.filter,
+	}
+	if t.page != nil {
+		s.PageNum = t.page.PageNum
+	}
+	for _, n := range slices.Sorted(maps.Keys(t.pages)) {
+		s.Pages = append(s.Pages, t.pages[n])
+	}
+	return s
+}
+
+// filterResults keeps the results whose title, URL or snippet contain every
+// word of filter, ignoring case.
+func filterResults(results []search.Result, filter string) []search.Result {
+	words := strings.Fields(strings.ToLower(filter))
+	if len(words) == 0 {
+		return results
+	}
+	var kept []search.Result
+	for _, r := range results {
+		text := strings.ToLower(r.Title + " " + r.URL + " " + r.Snippet)
+		if !slices.ContainsFunc(words, func(w string) bool { return !strings.Contains(text, w) }) {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+// openTab adds a tab on the current engine and settings and switches to
+// it, searching for query if one is given.
+func (m Model) openTab(query string) (tea.Model, tea.Cmd) {
+	history, kills := m.input.history, m.input.killRing
+	m.tabs[m.active] = m.tab
+	m.nextID++
+	opts := m.opts
+	opts.Region, opts.SafeSearch = m.region, m.safe
+	m.tab = newTab(m.nextID, m.backend, opts)
+	m.input.history, m.input.killRing = history, kills
+	m.tabs = append(m.tabs, m.tab)
+	m.active = len(m.tabs) - 1
+	m.layout()
+	if query == "" {
+		return m, m.input.Focus()
+	}
+	return m.runQuery(query, false)
+}
+
+// closeTab closes the active tab. Searches still running in it are dropped.
+func (m Model) closeTab() (tea.Model, tea.Cmd) {
+	if len(m.tabs) == 1 {
+		m.errMsg = "cannot close the last tab"
+		return m, nil
+	}
+	m.tabs = slices.Delete(slices.Clone(m.tabs), m.active, m.active+1)
+	return m.showTab(min(m.active, len(m.tabs)-1))
+}
+
+// switchTab activates tab i, wrapping around at either end.
+func (m Model) switchTab(i int) (tea.Model, tea.Cmd) {
+	n := len(m.tabs)
+	if n < 2 {
+		return m, nil
+	}
+	m.tabs[m.active] = m.tab
+	return m.showTab((i%n + n) % n)
+}
+
+// showTab makes tabs[i] the active tab. The search history and the kill
+// ring follow the user across tabs.
+func (m Model) showTab(i int) (tea.Model, tea.Cmd) {
+	history, kills := m.input.history, m.input.killRing
+	m.suggestSeq++
+	m.cancelSuggest()
+	m.input.ClearSuggestions()
+
+	m.active = i
+	m.tab = m.tabs[i]
+	m.input.history, m.input.killRing = history, kills
+	m.done = false
+	m.layout()
+
+	var cmd tea.Cmd
+	switch m.state {
+	case stateLoading:
+		cmd = m.spinner.Tick
+	case stateInput:
+		cmd = m.input.Focus()
+	}
+	return m, cmd
+}
+
+// finishBackground applies a search result that arrived for an inactive
+// tab and flags it for the tab bar.
+func (m *Model) finishBackground(msg searchResultMsg) tea.Cmd {
+	i := slices.IndexFunc(m.tabs, func(t tab) bool { return t.id == msg.tab })
+	if i < 0 {
+		return nil
+	}
+	t := &m.tabs[i]
+	t.done = true
+	if msg.err != nil {
+		t.errMsg = msg.err.Error()
+		t.state = stateInput
+		return nil
+	}
+	t.errMsg = ""
+	t.cachePage(msg.page)
+	t.showPage(msg.page, m.opts.ShowAds)
+	t.state = stateResults
+	t.input.Blur()
+	return m.record(t.query, t.backend.Name(), msg.page.Results)
+}
+
+// renderTabBar renders one label per tab, or "" with a single tab.
+func (m Model) renderTabBar() string {
+	if len(m.tabs) < 2 {
+		return ""
+	}
+	var b strings.Builder
+	for i, t := range m.tabs {
+		if i == m.active {
+			t = m.tab
+		}
+		label := fmt.Sprintf(" %d:%s ", i+1, truncate(cmp.Or(t.query, "new"), maxTabLabel))
+		switch {
+		case i == m.active:
+			b.WriteString(tabActiveStyle.Render(label))
+		case t.done:
+			b.WriteString(tabDoneStyle.Render(label + "● "))
+		case t.state == stateLoading:
+			b.WriteString(tabStyle.Render(label + "… "))
+		default:
+			b.WriteString(tabStyle.Render(label))
+		}
+		b.WriteString(" ")
+	}
+	return b.String()
+}
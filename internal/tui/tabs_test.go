@@ -0,0 +1,97 @@
+package tui
+
+import (
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// threeTabs returns a model with tabs searching "a", "b" and "c", the last
+// one active.
+func threeTabs() Model {
+	m := NewModel("", suggestBackend{}, Options{})
+	for _, q := range []string{"a", "b", "c"} {
+		if q != "a" {
+			next, _ := m.openTab("")
+			m = next.(Model)
+		}
+		m.query = q
+	}
+	return m
+}
+
+func tabQueries(m Model) []string {
+	var queries []string
+	for i, t := range m.tabs {
+		if i == m.active {
+			t = m.tab
+		}
+		queries = append(queries, t.query)
+	}
+	return queries
+}
+
+// tabOp is one step of a tab test.
+type tabOp = func(Model) (tea.Model, tea.Cmd)
+
+func TestTabs(t *testing.T) {
+	next := func(m Model) (tea.Model, tea.Cmd) { return m.switchTab(m.active + 1) }
+	prev := func(m Model) (tea.Model, tea.Cmd) { return m.switchTab(m.active - 1) }
+	first := func(m Model) (tea.Model, tea.Cmd) { return m.switchTab(0) }
+	closeTab := Model.closeTab
+	open := func(m Model) (tea.Model, tea.Cmd) { return m.openTab("") }
+
+	tests := []struct {
+		name    string
+		ops     []tabOp
+		active  int
+		queries []string
+		err     bool
+	}{
+		{"next wraps", []tabOp{next}, 0, []string{"a", "b", "c"}, false},
+		{"prev", []tabOp{prev}, 1, []string{"a", "b", "c"}, false},
+		{"prev wraps", []tabOp{first, prev}, 2, []string{"a", "b", "c"}, false},
+		{"open appends", []tabOp{first, open}, 3, []string{"a", "b", "c", ""}, false},
+		{"close last", []tabOp{closeTab}, 1, []string{"a", "b"}, false},
+		{"close first", []tabOp{first, closeTab}, 0, []string{"b", "c"}, false},
+		{"close middle", []tabOp{prev, closeTab}, 1, []string{"a", "c"}, false},
+		{"keep one", []tabOp{closeTab, closeTab, closeTab}, 0, []string{"a"}, true},
+		{"switch one", []tabOp{closeTab, closeTab, next}, 0, []string{"a"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := threeTabs()
+			for _, op := range tt.ops {
+				n, _ := op(m)
+				m = n.(Model)
+			}
+			if m.active != tt.active || m.query != tt.queries[tt.active] {
+				t.Errorf("active tab %d searching %q, want %d", m.active, m.query, tt.active)
+			}
+			if got := tabQueries(m); !slices.Equal(got, tt.queries) {
+				t.Errorf("tabs = %q, want %q", got, tt.queries)
+			}
+			if (m.errMsg != "") != tt.err {
+				t.Errorf("error = %q", m.errMsg)
+			}
+		})
+	}
+}
+
+func TestTabIDsStayUnique(t *testing.T) {
+	m := threeTabs()
+	n, _ := m.closeTab()
+	n, _ = n.(Model).openTab("")
+	m = n.(Model)
+	seen := map[int]bool{}
+	for i, tb := range m.tabs {
+		if i == m.active {
+			tb = m.tab
+		}
+		if seen[tb.id] {
+			t.Fatalf("tab id %d used twice", tb.id)
+		}
+		seen[tb.id] = true
+	}
+}
@@ -1,14 +1,25 @@
 package tui
 
 import (
+	"cmp"
+	"context"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/frort/ksk/internal/bang"
 	"github.com/frort/ksk/internal/browser"
 	"github.com/frort/ksk/internal/search"
+	"github.com/frort/ksk/internal/session"
 )
 
 type state int
@@ -21,47 +32,215 @@ const (
 
 // Messages
 type searchResultMsg struct {
+	tab  int // id of the tab that started the search
 	page *search.Page
 	err  error
 }
 
+// Options configures optional Model behaviour.
+type Options struct {
+	// Sanitizer cleans URLs before they are opened or copied. Nil disables cleaning.
+	Sanitizer *search.Sanitizer
+	// ShowAds keeps sponsored results, rendered dimmed with an "Ad" badge.
+	ShowAds bool
+	// Region is passed to backends created at runtime, e.g. by a bang.
+	Region string
+	// Language is the BCP 47 tag passed with Region.
+	Language string
+	// Bangs resolves "!trigger" prefixes. Nil disables bangs.
+	Bangs *bang.DB
+	// Recorder receives every results page and opened URL, e.g. to build the
+	// local index. Nil disables recording.
+	Recorder Recorder
+	// Session restores a saved state on startup.
+	Session *session.Session
+	// SessionName is where Ctrl+S saves the session.
+	SessionName string
+	// SafeSearch is passed to backends created at runtime.
+	SafeSearch search.SafeSearch
+	// SnippetLines caps the lines of snippet per result; 0 means 2.
+	SnippetLines int
+	// ListLayout starts with one line per result instead of blocks.
+	ListLayout bool
+	// Keys replaces the default key bindings.
+	Keys *KeyMap
+	// CharLimit caps the query length; 0 means 256 and a negative value
+	// lifts the limit.
+	CharLimit int
+	// Editor runs an external editor on the query; empty uses $VISUAL or
+	// $EDITOR.
+	Editor string
+	// Engines are the engines e and E cycle through; empty means every
+	// registered one.
+	Engines []string
+}
+
+func (o Options) keyMap() KeyMap {
+	if o.Keys != nil {
+		return *o.Keys
+	}
+	return DefaultKeyMap()
+}
+
+// Recorder is notified of results shown and pages opened.
+type Recorder interface {
+	Record(query, engine string, results []search.Result) error
+	RecordOpen(url string) error
+}
+
+// recordErrMsg reports that the Recorder failed. The search itself went
+// fine, so it is shown as a notice.
+type recordErrMsg struct{ err error }
+
+// minHelpWidth is the least room worth showing key hints in.
+const minHelpWidth = 12
+
+// suggestDelay debounces autocomplete requests while typing.
+const suggestDelay = 200 * time.Millisecond
+
+type suggestDebounceMsg struct {
+	seq   int
+	query string
+}
+
+type suggestResultMsg struct {
+	seq         int
+	suggestions []string
+	err         error
+}
+
 type Model struct {
-	state   state
-	input   inputModel
-	results resultsModel
+	tab // the active tab
+
+	// tabs holds every tab in bar order; tabs[active] is stale while active
+	tabs   []tab
+	active int
+	nextID int
+
 	spinner spinner.Model
-	query   string
-	page    *search.Page
-	errMsg  string
+	notice  string // transient message shown in the status bar
 	width   int
 	height  int
-	backend search.Backend
+	opts    Options
+
+	// pendingG is set after "g" so that "gt"/"gT" can switch tabs; beforeG
+	// undoes the jump to the top that "g" already made.
+	pendingG bool
+	beforeG  resultsModel
+
+	// count is the numeric prefix typed in results mode, e.g. the 5 of "5j"
+	count int
+	// hint is the action of an active hint mode; hintKeys holds the label
+	// letters typed so far
+	hint     hintAction
+	hintKeys string
+
+	commanding bool // the ":" command line has focus
+	cmdline    textinput.Model
+	cmdHint    string // completions listed above the command line
+
+	picker *regionPicker // open region picker, if any
+
+	// lastClick and lastClickIdx detect a double click on a result
+	lastClick    time.Time
+	lastClickIdx int
+
+	keys     KeyMap
+	help     help.Model // renders the key hints of the status bar
+	showHelp bool       // the help overlay replaces the results
+
+	// Autocomplete: seq identifies the latest request, cancel aborts it
+	suggestSeq    int
+	suggestCancel context.CancelFunc
 }
 
-func NewModel(initialQuery string, backend search.Backend) Model {
+func NewModel(initialQuery string, backend search.Backend, opts Options) Model {
 	s := spinner.New()
 	s.Spinner = spinner.Dot
 	s.Style = spinnerStyle
 
 	m := Model{
-		state:   stateInput,
-		input:   newInputModel("search the web..."),
-		results: newResultsModel(),
+		tab:     newTab(0, backend, opts),
 		spinner: s,
-		backend: backend,
+		opts:    opts,
+		cmdline: newCommandLine(),
+		keys:    opts.keyMap(),
+		help:    newHelp(),
+	}
+	m.tabs = []tab{m.tab}
+
+	if opts.Session != nil && initialQuery == "" {
+		m.restore(opts.Session)
+		return m
+	}
+	if opts.Session != nil {
+		m.input.history = opts.Session.History
 	}
 
 	if initialQuery != "" {
 		m.query = initialQuery
 		m.input.SetValue(initialQuery)
+		m.input.AddHistory(initialQuery)
 		m.state = stateLoading
 	}
 
 	return m
 }
 
+// restore shows a saved session without contacting the engines. The active
+// tab keeps the backend and settings given to NewModel; the other tabs are
+// created from their saved engine and settings, and dropped when that
+// fails.
+func (m *Model) restore(s *session.Session) {
+	var tabs []tab
+	var errs []string
+	active := 0
+	for i, st := range s.Tabs {
+		t := m.tab
+		if i != s.Active {
+			opts := m.opts
+			opts.Region, opts.SafeSearch = st.Region, st.SafeSearch
+			backend, err := search.New(st.Engine, search.Options{Region: st.Region, Language: opts.Language, SafeSearch: st.SafeSearch})
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("tab %d: %v", i+1, err))
+				continue
+			}
+			m.nextID++
+			t = newTab(m.nextID, backend, opts)
+		} else {
+			active = len(tabs)
+		}
+		t.restore(st, m.opts.ShowAds)
+		tabs = append(tabs, t)
+	}
+	if len(tabs) > 0 {
+		m.tabs, m.active = tabs, active
+		m.tab = tabs[active]
+	}
+	m.input.history = s.History
+	if len(errs) > 0 {
+		m.errMsg = strings.Join(errs, "; ")
+	}
+}
+
+// Snapshot captures the state to save as a session.
+func (m Model) Snapshot() *session.Session {
+	s := &session.Session{
+		Active:   m.active,
+		Language: m.opts.Language,
+		History:  m.input.history,
+	}
+	for i, t := range m.tabs {
+		if i == m.active {
+			t = m.tab
+		}
+		s.Tabs = append(s.Tabs, t.snapshot())
+	}
+	return s
+}
+
 func (m Model) Init() tea.Cmd {
-	cmds := []tea.Cmd{m.input.textInput.Cursor.BlinkCmd()}
+	cmds := []tea.Cmd{textarea.Blink}
 	if m.state == stateLoading {
 		cmds = append(cmds, m.spinner.Tick, m.doSearch(m.query))
 	}
@@ -73,23 +252,55 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
-		// Reserve space for input(1) + status(1) + padding(2)
-		m.results.SetSize(msg.Width, msg.Height-4)
+		m.layout()
 		return m, nil
 
 	case searchResultMsg:
+		if msg.tab != m.id {
+			cmd := m.finishBackground(msg)
+			return m, cmd
+		}
 		if msg.err != nil {
 			m.errMsg = msg.err.Error()
 			m.state = stateInput
 			return m, m.input.Focus()
 		}
-		m.page = msg.page
 		m.errMsg = ""
-		m.results.SetResults(msg.page.Results, msg.page.PageNum, msg.page.HasMore)
+		m.cachePage(msg.page)
+		m.showPage(msg.page, m.opts.ShowAds)
 		m.state = stateResults
 		m.input.Blur()
+		return m, m.record(m.query, m.backend.Name(), msg.page.Results)
+
+	case suggestDebounceMsg:
+		if msg.seq != m.suggestSeq || m.state != stateInput {
+			return m, nil
+		}
+		cmd := m.fetchSuggestions(msg)
+		return m, cmd
+
+	case suggestResultMsg:
+		if msg.seq != m.suggestSeq || m.state != stateInput || msg.err != nil {
+			return m, nil
+		}
+		m.input.SetSuggestions(msg.suggestions)
+		return m, nil
+
+	case recordErrMsg:
+		m.notice = "Index: " + msg.err.Error()
 		return m, nil
 
+	case editorDoneMsg:
+		if msg.err != nil {
+			m.errMsg = msg.err.Error()
+			return m, nil
+		}
+		m.errMsg = ""
+		m.input.SetValue(msg.query)
+		m.layout()
+		cmd := m.scheduleSuggest()
+		return m, cmd
+
 	case spinner.TickMsg:
 		if m.state == stateLoading {
 			var cmd tea.Cmd
@@ -99,37 +310,100 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
+	if m.picker != nil {
+		return m.updatePicker(msg)
+	}
+	if m.commanding {
+		return m.updateCommand(msg)
+	}
+	if m.hint != hintNone {
+		return m.updateHint(msg)
+	}
+	if m.showHelp {
+		if msg, ok := msg.(tea.KeyMsg); ok {
+			switch {
+			case key.Matches(msg, m.keys.Help), msg.Type == tea.KeyEsc, msg.String() == "q":
+				m.showHelp = false
+			case msg.Type == tea.KeyCtrlC:
+				return m, tea.Quit
+			}
+		}
+		return m, nil
+	}
+	if msg, ok := msg.(tea.MouseMsg); ok {
+		if m.state != stateResults {
+			return m, nil
+		}
+		return m.updateMouse(msg)
+	}
+
 	switch m.state {
 	case stateInput:
 		return m.updateInput(msg)
 	case stateResults:
 		return m.updateResults(msg)
+	case stateLoading:
+		return m.updateLoading(msg)
 	}
 
 	return m, nil
 }
 
+// layout sizes the active tab's results to the window.
+func (m *Model) layout() {
+	// Reserve space for input + status(1) + padding(2)
+	m.input.SetWidth(m.width)
+	h := m.height - 3 - m.input.Height()
+	if bar := m.renderTabBar(); bar != "" {
+		h -= lipgloss.Height(bar)
+	}
+	m.results.SetSize(m.width, h)
+	m.results.ensureVisible()
+}
+
 func (m Model) updateInput(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.Type {
-		case tea.KeyCtrlC:
+		// In a query of several lines the suggestion keys move between lines
+		switch {
+		case key.Matches(msg, m.keys.NextSuggestion) && !m.input.Multiline():
+			m.input.MoveSelection(1)
+			return m, nil
+		case key.Matches(msg, m.keys.PrevSuggestion) && !m.input.Multiline():
+			m.input.MoveSelection(-1)
+			return m, nil
+		case key.Matches(msg, m.keys.AcceptSuggestion):
+			if m.input.AcceptSuggestion() {
+				cmd := m.scheduleSuggest()
+				return m, cmd
+			}
+			return m, nil
+		case key.Matches(msg, m.keys.Interrupt):
 			return m, tea.Quit
-		case tea.KeyCtrlD:
+		case key.Matches(msg, m.keys.EOF):
 			if m.input.Value() == "" {
 				return m, tea.Quit
 			}
-		case tea.KeyEnter:
-			q := m.input.Value()
+		case key.Matches(msg, m.keys.Submit):
+			q := m.input.Selected()
 			if q == "" {
+				q = m.input.Value()
+			}
+			if joinLines(q) == "" {
+				return m, nil
+			}
+			if b, rest, ok := m.resolveBang(joinLines(q)); ok {
+				return m.runBang(b, rest)
+			}
+			return m.runQuery(q, false)
+		case key.Matches(msg, m.keys.Editor):
+			return m, m.editQuery()
+		case key.Matches(msg, m.keys.Back):
+			if m.input.HasSuggestions() {
+				m.cancelSuggest()
+				m.input.ClearSuggestions()
 				return m, nil
 			}
-			m.query = q
-			m.state = stateLoading
-			m.errMsg = ""
-			m.input.Blur()
-			return m, tea.Batch(m.spinner.Tick, m.doSearch(q))
-		case tea.KeyEsc:
 			if len(m.results.results) > 0 {
 				m.state = stateResults
 				m.input.Blur()
@@ -138,45 +412,168 @@ func (m Model) updateInput(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	}
 
+	prev, height := m.input.Value(), m.input.Height()
 	var cmd tea.Cmd
 	m.input, cmd = m.input.Update(msg)
+	if m.input.Height() != height {
+		m.layout()
+	}
+	if m.input.Value() != prev {
+		cmd = tea.Batch(cmd, m.scheduleSuggest())
+	}
 	return m, cmd
 }
 
+// scheduleSuggest refreshes the dropdown from local data and starts the
+// debounce timer for an engine request.
+func (m *Model) scheduleSuggest() tea.Cmd {
+	m.suggestSeq++
+	m.cancelSuggest()
+	m.input.RefreshSuggestions()
+
+	q := m.input.Value()
+	if _, ok := m.backend.(search.Suggester); !ok || strings.TrimSpace(q) == "" || m.input.Multiline() {
+		return nil
+	}
+	seq := m.suggestSeq
+	return tea.Tick(suggestDelay, func(time.Time) tea.Msg {
+		return suggestDebounceMsg{seq: seq, query: q}
+	})
+}
+
+func (m *Model) fetchSuggestions(msg suggestDebounceMsg) tea.Cmd {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.suggestCancel = cancel
+	suggester := m.backend.(search.Suggester)
+	return func() tea.Msg {
+		list, err := suggester.Suggest(ctx, msg.query)
+		return suggestResultMsg{seq: msg.seq, suggestions: list, err: err}
+	}
+}
+
+// cancelSuggest aborts an in-flight autocomplete request, if any.
+func (m *Model) cancelSuggest() {
+	if m.suggestCancel != nil {
+		m.suggestCancel()
+		m.suggestCancel = nil
+	}
+}
+
 func (m Model) updateResults(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "q", "ctrl+c":
+		m.notice = ""
+		if m.pendingG {
+			m.pendingG = false
+			switch msg.String() {
+			case "t":
+				m.results = m.beforeG
+				return m.switchTab(m.active + 1)
+			case "T":
+				m.results = m.beforeG
+				return m.switchTab(m.active - 1)
+			}
+		}
+		if m.countKey(msg.String()) {
+			return m, nil
+		}
+		count := m.count
+		m.count = 0
+		switch {
+		case key.Matches(msg, m.keys.Quit):
 			return m, tea.Quit
-		case "j", "down":
-			m.results.CursorDown()
-		case "k", "up":
-			m.results.CursorUp()
-		case "g":
+		case key.Matches(msg, m.keys.Down):
+			for range max(count, 1) {
+				m.results.CursorDown()
+			}
+		case key.Matches(msg, m.keys.Up):
+			for range max(count, 1) {
+				m.results.CursorUp()
+			}
+		case key.Matches(msg, m.keys.Top):
+			m.beforeG = m.results
+			m.pendingG = true
 			m.results.CursorTop()
-		case "G":
-			m.results.CursorBottom()
-		case "enter", "o":
+		case key.Matches(msg, m.keys.Bottom):
+			// With a count, "G" goes to that result like a line in vi
+			if count > 0 {
+				m.results.SetCursor(count - 1)
+			} else {
+				m.results.CursorBottom()
+			}
+		case key.Matches(msg, m.keys.Open):
+			if count > 0 {
+				return m.cmdOpen(strconv.Itoa(count))
+			}
 			if r := m.results.SelectedResult(); r != nil {
-				_ = browser.Open(r.URL)
+				_ = browser.Open(m.cleanURL(r.URL))
+				return m, m.recordOpen(r.URL)
+			}
+		case key.Matches(msg, m.keys.Yank):
+			if count > 0 {
+				return m.cmdYank(strconv.Itoa(count))
 			}
-		case "y":
 			if r := m.results.SelectedResult(); r != nil {
-				_ = clipboard.WriteAll(r.URL)
+				_ = clipboard.WriteAll(m.cleanURL(r.URL))
+			}
+		case key.Matches(msg, m.keys.Expand):
+			m.results.ToggleExpanded()
+		case key.Matches(msg, m.keys.Layout):
+			m.setListLayout(!m.results.list)
+		case key.Matches(msg, m.keys.HintOpen):
+			m.startHint(hintOpen)
+		case key.Matches(msg, m.keys.HintYank):
+			m.startHint(hintYank)
+		case key.Matches(msg, m.keys.OpenAnswer):
+			if a := m.results.Answer(); a != nil && a.URL != "" {
+				_ = browser.Open(m.cleanURL(a.URL))
+			}
+		case key.Matches(msg, m.keys.YankAnswer):
+			if a := m.results.Answer(); a != nil && a.URL != "" {
+				_ = clipboard.WriteAll(m.cleanURL(a.URL))
 			}
-		case "/":
+		case key.Matches(msg, m.keys.NextRelated):
+			m.results.NextRelated(1)
+		case key.Matches(msg, m.keys.PrevRelated):
+			m.results.NextRelated(-1)
+		case key.Matches(msg, m.keys.SearchRelated):
+			if q := m.results.SelectedRelated(); q != "" {
+				return m.runQuery(q, false)
+			}
+		case key.Matches(msg, m.keys.Correction):
+			// An applied correction without Original cannot be undone
+			if c := m.results.Correction(); c != nil && !c.Applied {
+				return m.runQuery(c.Query, false)
+			} else if c != nil && c.Original != "" {
+				return m.runQuery(c.Original, true)
+			}
+		case key.Matches(msg, m.keys.Search):
 			m.state = stateInput
 			return m, m.input.Focus()
-		case "l", "right":
-			if m.page != nil && m.page.HasMore {
-				m.state = stateLoading
-				return m, tea.Batch(m.spinner.Tick, m.doNextPage())
-			}
-		case "h", "left":
-			if m.page != nil && m.page.PageNum > 1 {
-				m.state = stateLoading
-				return m, tea.Batch(m.spinner.Tick, m.doPrevPage())
+		case key.Matches(msg, m.keys.Command):
+			cmd := m.openCommandLine()
+			return m, cmd
+		case key.Matches(msg, m.keys.NextEngine):
+			return m.cycleEngine(1)
+		case key.Matches(msg, m.keys.PrevEngine):
+			return m.cycleEngine(-1)
+		case key.Matches(msg, m.keys.Region):
+			p := newRegionPicker(m.region, m.backend)
+			m.picker = &p
+		case key.Matches(msg, m.keys.NextPage):
+			cmd := m.nextPage()
+			return m, cmd
+		case key.Matches(msg, m.keys.PrevPage):
+			cmd := m.prevPage()
+			return m, cmd
+		case key.Matches(msg, m.keys.Help):
+			m.showHelp = true
+		case key.Matches(msg, m.keys.SaveSession):
+			name := cmp.Or(m.opts.SessionName, session.LastName)
+			if err := session.Save(name, m.Snapshot()); err != nil {
+				m.errMsg = err.Error()
+			} else {
+				m.notice = "Session saved: " + name
 			}
 		}
 	}
@@ -184,9 +581,80 @@ func (m Model) updateResults(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-func (m Model) View() string {
+// setListLayout switches every tab between one line per result and blocks.
+func (m *Model) setListLayout(list bool) {
+	m.opts.ListLayout = list
+	m.results.SetList(list)
+	for i := range m.tabs {
+		m.tabs[i].results.SetList(list)
+	}
+}
+
+// nextPage shows the next page, from the cache when it was loaded before.
+func (m *Model) nextPage() tea.Cmd {
+	if m.page == nil || !m.page.HasMore {
+		return nil
+	}
+	if p, ok := m.pages[m.page.PageNum+1]; ok {
+		m.showPage(p, m.opts.ShowAds)
+		return nil
+	}
+	m.state = stateLoading
+	return tea.Batch(m.spinner.Tick, m.doNextPage())
+}
+
+// prevPage shows the previous page, from the cache when it was loaded before.
+func (m *Model) prevPage() tea.Cmd {
+	if m.page == nil || m.page.PageNum <= 1 {
+		return nil
+	}
+	if p, ok := m.pages[m.page.PageNum-1]; ok {
+		m.showPage(p, m.opts.ShowAds)
+		return nil
+	}
+	m.state = stateLoading
+	return tea.Batch(m.spinner.Tick, m.doPrevPage())
+}
+
+// updateLoading lets the user leave a tab while its search runs.
+func (m Model) updateLoading(msg tea.Msg) (tea.Model, tea.Cmd) {
+	k, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	pending := m.pendingG
+	m.pendingG = false
+	switch k.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "g":
+		m.pendingG = true
+	case "t":
+		if pending {
+			return m.switchTab(m.active + 1)
+		}
+	case "T":
+		if pending {
+			return m.switchTab(m.active - 1)
+		}
+	default:
+		if key.Matches(k, m.keys.Command) {
+			cmd := m.openCommandLine()
+			return m, cmd
+		}
+	}
+	return m, nil
+}
+
+// topSections renders what View shows above the results: the tab bar, the
+// input bar and any error.
+func (m Model) topSections() []string {
 	var sections []string
 
+	if bar := m.renderTabBar(); bar != "" {
+		sections = append(sections, bar)
+	}
+
 	// Input bar
 	sections = append(sections, m.input.View())
 
@@ -194,52 +662,232 @@ func (m Model) View() string {
 	if m.errMsg != "" {
 		sections = append(sections, errorStyle.Render("Error: "+m.errMsg))
 	}
+	return sections
+}
 
-	switch m.state {
-	case stateLoading:
+func (m Model) View() string {
+	sections := m.topSections()
+
+	switch {
+	case m.showHelp:
+		sections = append(sections, m.keys.renderHelp(m.width))
+
+	case m.picker != nil:
+		sections = append(sections, m.picker.View())
+
+	case m.state == stateLoading:
 		sections = append(sections, fmt.Sprintf("\n  %s Searching...\n", m.spinner.View()))
 
-	case stateResults:
+	case m.state == stateResults:
 		sections = append(sections, m.results.View())
 
-	case stateInput:
-		if len(m.results.results) > 0 {
+	case m.state == stateInput:
+		// The autocomplete dropdown takes the place of the results
+		if len(m.results.results) > 0 && !m.input.HasSuggestions() {
 			sections = append(sections, m.results.View())
 		}
 	}
 
 	// Status bar
-	if m.state == stateResults || (m.state == stateInput && len(m.results.results) > 0) {
-		sections = append(sections, statusBar.Render(m.results.StatusView(m.backend.Name())))
+	if m.commanding {
+		if m.cmdHint != "" {
+			sections = append(sections, statusBar.Render(m.cmdHint))
+		}
+		sections = append(sections, m.cmdline.View())
+	} else if m.state == stateResults || (m.state == stateInput && len(m.results.results) > 0) {
+		status := m.results.StatusView(m.backend.Name(), m.region)
+		if m.filter != "" {
+			status += " | filter: " + m.filter
+		}
+		switch {
+		case m.hint == hintOpen:
+			status += " | open: type a label"
+		case m.hint == hintYank:
+			status += " | copy: type a label"
+		case m.count > 0:
+			status += " | " + strconv.Itoa(m.count)
+		}
+		if m.notice != "" {
+			status += " | " + m.notice
+		}
+		// Key hints fill whatever width is left, dropping the last ones
+		m.help.Width = m.width - statusBar.GetHorizontalPadding() - lipgloss.Width(status) - 3
+		if m.help.Width >= minHelpWidth {
+			status += " | " + m.help.ShortHelpView(m.keys.shortHelp())
+		}
+		if m.width > 0 {
+			status = truncate(status, m.width-statusBar.GetHorizontalPadding())
+		}
+		sections = append(sections, statusBar.Render(status))
 	}
 
 	return lipgloss.JoinVertical(lipgloss.Left, sections...)
 }
 
-func (m Model) doSearch(query string) tea.Cmd {
-	backend := m.backend
+func filterAds(results []search.Result, showAds bool) []search.Result {
+	if showAds {
+		return results
+	}
+	var organic []search.Result
+	for _, r := range results {
+		if !r.IsAd() {
+			organic = append(organic, r)
+		}
+	}
+	return organic
+}
+
+// record hands results to the Recorder in the background.
+func (m Model) record(query, engine string, results []search.Result) tea.Cmd {
+	rec := m.opts.Recorder
+	if rec == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		if err := rec.Record(query, engine, results); err != nil {
+			return recordErrMsg{err}
+		}
+		return nil
+	}
+}
+
+func (m Model) recordOpen(url string) tea.Cmd {
+	rec := m.opts.Recorder
+	if rec == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		if err := rec.RecordOpen(url); err != nil {
+			return recordErrMsg{err}
+		}
+		return nil
+	}
+}
+
+func (m Model) cleanURL(u string) string {
+	if m.opts.Sanitizer == nil {
+		return u
+	}
+	return m.opts.Sanitizer.Clean(u)
+}
+
+func (m Model) resolveBang(q string) (bang.Bang, string, bool) {
+	if m.opts.Bangs == nil {
+		return bang.Bang{}, "", false
+	}
+	return m.opts.Bangs.Resolve(q)
+}
+
+// runBang opens a site search in the browser, or switches to the bang's
+// engine and searches there.
+func (m Model) runBang(b bang.Bang, q string) (tea.Model, tea.Cmd) {
+	if b.Engine == "" {
+		m.input.AddHistory(m.input.Value())
+		m.input.ClearSuggestions()
+		if err := browser.Open(b.Expand(q)); err != nil {
+			m.errMsg = err.Error()
+		} else {
+			m.errMsg = ""
+			m.input.SetValue("")
+		}
+		return m, nil
+	}
+
+	backend, err := m.newBackend(b.Engine)
+	if err != nil {
+		m.errMsg = err.Error()
+		return m, nil
+	}
+	m.backend = backend
+	if q == "" {
+		m.input.SetValue("")
+		m.input.ClearSuggestions()
+		return m, nil
+	}
+	return m.runQuery(q, false)
+}
+
+// runQuery starts a new search for q. literal disables the engine's spelling
+// correction where supported. A query written over several lines keeps its
+// lines in the prompt and is searched as one line.
+func (m Model) runQuery(q string, literal bool) (tea.Model, tea.Cmd) {
+	m.pages = nil
+	m.filter = ""
+	m.suggestSeq++
+	m.cancelSuggest()
+	m.query = joinLines(q)
+	m.input.SetValue(q)
+	m.input.AddHistory(q)
+	m.state = stateLoading
+	m.errMsg = ""
+	m.input.Blur()
+	m.layout()
+	cmd := m.doSearch(m.query)
+	if literal {
+		cmd = m.doSearchLiteral(m.query)
+	}
+	return m, tea.Batch(m.spinner.Tick, cmd)
+}
+
+// newBackend creates engine name with the active tab's region and safe
+// search.
+func (m Model) newBackend(name string) (search.Backend, error) {
+	return m.newTabBackend(name, m.region, m.safe)
+}
+
+// newTabBackend creates engine name for the active tab with the given
+// region and safe search level.
+func (m Model) newTabBackend(name, region string, safe search.SafeSearch) (search.Backend, error) {
+	return search.New(name, search.Options{Region: region, Language: m.opts.Language, SafeSearch: safe})
+}
+
+func (t tab) doSearchLiteral(query string) tea.Cmd {
+	ls, ok := t.backend.(search.LiteralSearcher)
+	if !ok {
+		return t.doSearch(query)
+	}
+	id := t.id
+	return func() tea.Msg {
+		page, err := ls.SearchLiteral(query)
+		return searchResultMsg{tab: id, page: page, err: err}
+	}
+}
+
+func (t tab) doSearch(query string) tea.Cmd {
+	id := t.id
+	backend := t.backend
 	return func() tea.Msg {
 		page, err := backend.Search(query)
-		return searchResultMsg{page: page, err: err}
+		return searchResultMsg{tab: id, page: page, err: err}
 	}
 }
 
-func (m Model) doNextPage() tea.Cmd {
-	page := m.page
-	query := m.query
-	backend := m.backend
+func (t tab) doNextPage() tea.Cmd {
+	id := t.id
+	page := t.page
+	query := t.query
+	backend := t.backend
 	return func() tea.Msg {
 		next, err := backend.NextPage(page, query)
-		return searchResultMsg{page: next, err: err}
+		return searchResultMsg{tab: id, page: next, err: err}
 	}
 }
 
-func (m Model) doPrevPage() tea.Cmd {
-	query := m.query
-	pageNum := m.page.PageNum - 1
-	backend := m.backend
+func (t tab) doPrevPage() tea.Cmd {
+	return t.doPage(t.page.PageNum - 1)
+}
+
+// doPage fetches page pageNum of the current query, keeping spelling
+// correction off when the current page was searched literally.
+func (t tab) doPage(pageNum int) tea.Cmd {
+	id := t.id
+	query := t.query
+	fetch := t.backend.PrevPage
+	if ls, ok := t.backend.(search.LiteralSearcher); ok && t.page != nil && t.page.Literal {
+		fetch = ls.PrevPageLiteral
+	}
 	return func() tea.Msg {
-		prev, err := backend.PrevPage(query, pageNum)
-		return searchResultMsg{page: prev, err: err}
+		prev, err := fetch(query, pageNum)
+		return searchResultMsg{tab: id, page: prev, err: err}
 	}
 }
@@ -0,0 +1,234 @@
+package tui
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/frort/ksk/internal/search"
+	"github.com/frort/ksk/internal/session"
+)
+
+// suggestBackend is a backend that only completes queries.
+type suggestBackend struct{}
+
+func (suggestBackend) Search(string) (*search.Page, error)                 { return &search.Page{PageNum: 1}, nil }
+func (suggestBackend) NextPage(*search.Page, string) (*search.Page, error) { return nil, nil }
+func (suggestBackend) PrevPage(string, int) (*search.Page, error)          { return nil, nil }
+func (suggestBackend) Name() string                                        { return "stub" }
+func (suggestBackend) Suggest(_ context.Context, q string) ([]string, error) {
+	return []string{q + "lang"}, nil
+}
+
+func typeRunes(m Model, s string) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+	for _, r := range s {
+		var next tea.Model
+		next, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		m = next.(Model)
+	}
+	return m, cmd
+}
+
+func TestSuggestDebounce(t *testing.T) {
+	m := NewModel("", suggestBackend{}, Options{})
+	m, _ = typeRunes(m, "go")
+	if m.suggestSeq != 2 {
+		t.Fatalf("suggestSeq = %d after two keys, want 2", m.suggestSeq)
+	}
+
+	// A timer started before the last key press must not reach the engine
+	next, cmd := m.Update(suggestDebounceMsg{seq: 1, query: "g"})
+	m = next.(Model)
+	if cmd != nil {
+		t.Error("stale debounce tick started a request")
+	}
+
+	// nor may an answer to an earlier request replace the dropdown
+	next, _ = m.Update(suggestResultMsg{seq: 1, suggestions: []string{"stale"}})
+	m = next.(Model)
+	if slices.Contains(m.input.suggestions, "stale") {
+		t.Errorf("stale suggestions shown: %q", m.input.suggestions)
+	}
+
+	next, cmd = m.Update(suggestDebounceMsg{seq: 2, query: "go"})
+	m = next.(Model)
+	if cmd == nil {
+		t.Fatal("current debounce tick did not start a request")
+	}
+	msg, ok := cmd().(suggestResultMsg)
+	if !ok || msg.seq != 2 {
+		t.Fatalf("request returned %#v, want suggestResultMsg for seq 2", msg)
+	}
+	next, _ = m.Update(msg)
+	m = next.(Model)
+	if !slices.Contains(m.input.suggestions, "golang") {
+		t.Errorf("suggestions = %q, want golang", m.input.suggestions)
+	}
+}
+
+func TestRegionKeptWhenUnsupported(t *testing.T) {
+	m := NewModel("", &search.Brave{Region: "jp"}, Options{Region: "jp"})
+
+	next, _ := m.cmdRegion("zz")
+	m = next.(Model)
+	if m.errMsg == "" {
+		t.Error("unknown region not reported")
+	}
+	if m.region != "jp" {
+		t.Errorf("region = %q after a failed switch, want jp", m.region)
+	}
+
+	m.errMsg = ""
+	next, _ = m.cmdRegion("de")
+	m = next.(Model)
+	if m.errMsg != "" || m.region != "de" || m.backend.(*search.Brave).Region != "de" {
+		t.Errorf("switch to de: region %q, backend %+v, error %q", m.region, m.backend, m.errMsg)
+	}
+}
+
+func TestRegionAndSafeSearchArePerTab(t *testing.T) {
+	m := NewModel("", &search.Brave{Region: "jp"}, Options{Region: "jp"})
+	next, _ := m.openTab("")
+	next, _ = next.(Model).cmdRegion("de")
+	next, _ = next.(Model).cmdSet("safe=strict")
+	m = next.(Model)
+	if b := m.backend.(*search.Brave); m.region != "de" || b.Region != "de" || b.SafeSearch != search.SafeStrict {
+		t.Fatalf("new tab: region %q, backend %+v", m.region, b)
+	}
+
+	next, _ = m.switchTab(0)
+	m = next.(Model)
+	if b := m.backend.(*search.Brave); m.region != "jp" || m.safe != "" || b.Region != "jp" {
+		t.Errorf("first tab: region %q, safe %q, backend %+v", m.region, m.safe, b)
+	}
+	if m.opts.Region != "jp" || m.opts.SafeSearch != "" {
+		t.Errorf("defaults changed: %+v", m.opts)
+	}
+}
+
+func TestCycleEngineSkipsUnsupported(t *testing.T) {
+	// Marginalia has no regions, GitHub ignores them
+	engines := []string{"duckduckgo", "marginalia", "mojeek", "github"}
+	m := NewModel("", &search.DuckDuckGo{Region: "nz"}, Options{Region: "nz", Engines: engines})
+
+	next, _ := m.cycleEngine(1)
+	m = next.(Model)
+	if got := m.backend.Name(); got != "marginalia" {
+		t.Errorf("next engine = %s, want marginalia", got)
+	}
+	// Mojeek has no nz region
+	next, _ = m.cycleEngine(1)
+	m = next.(Model)
+	if got := m.backend.Name(); got != "github" {
+		t.Errorf("next engine = %s, want github", got)
+	}
+	next, _ = m.cycleEngine(-1)
+	m = next.(Model)
+	if got := m.backend.Name(); got != "marginalia" {
+		t.Errorf("previous engine = %s, want marginalia", got)
+	}
+}
+
+func TestCycleEngineFromOutsideTheCycle(t *testing.T) {
+	m := NewModel("", suggestBackend{}, Options{Engines: []string{"duckduckgo", "brave"}})
+	next, _ := m.cycleEngine(-1)
+	if got := next.(Model).backend.Name(); got != "brave" {
+		t.Errorf("previous engine = %s, want brave", got)
+	}
+	next, _ = m.cycleEngine(1)
+	if got := next.(Model).backend.Name(); got != "duckduckgo" {
+		t.Errorf("next engine = %s, want duckduckgo", got)
+	}
+}
+
+func TestEditorArgsSkipsBlankSettings(t *testing.T) {
+	t.Setenv("VISUAL", "  ")
+	t.Setenv("EDITOR", " nvim -f ")
+	args, err := editorArgs(" \t")
+	if err != nil || !slices.Equal(args, []string{"nvim", "-f"}) {
+		t.Errorf("editorArgs = %q, %v", args, err)
+	}
+
+	t.Setenv("EDITOR", "")
+	if args, err := editorArgs(""); err == nil {
+		t.Errorf("editorArgs with nothing set = %q, want an error", args)
+	}
+}
+
+func TestRestoreSession(t *testing.T) {
+	goPage := &search.Page{PageNum: 1, Results: []search.Result{{Title: "Go", URL: "https://go.dev/"}, {Title: "Go Tour", URL: "https://go.dev/tour/"}}}
+	s := &session.Session{
+		Tabs: []session.Tab{
+			{Query: "golang", Engine: "brave", Region: "de", SafeSearch: search.SafeStrict, Pages: []*search.Page{goPage}, PageNum: 1, Cursor: 1, Filter: "go"},
+			{Query: "rust", Engine: "duckduckgo", Region: "jp"},
+			{Query: "lost", Engine: "nope"},
+		},
+		Active:   1,
+		Language: "ja",
+		History:  []string{"golang", "rust"},
+	}
+	// main creates the active tab's backend from the session
+	m := NewModel("", &search.DuckDuckGo{Region: "jp"}, Options{Region: "jp", Language: "ja", Session: s})
+
+	if len(m.tabs) != 2 || m.active != 1 || m.query != "rust" || m.region != "jp" {
+		t.Fatalf("%d tabs, active %d: %q in %q", len(m.tabs), m.active, m.query, m.region)
+	}
+	if !strings.Contains(m.errMsg, "tab 3") {
+		t.Errorf("unknown engine not reported: %q", m.errMsg)
+	}
+	first := m.tabs[0]
+	if b, ok := first.backend.(*search.Brave); !ok || b.Region != "de" || b.SafeSearch != search.SafeStrict || b.Language != "ja" {
+		t.Errorf("first tab backend = %+v", first.backend)
+	}
+	if first.state != stateResults || first.results.cursor != 1 || first.filter != "go" || first.pages[1] != goPage {
+		t.Errorf("first tab state %v, cursor %d, filter %q", first.state, first.results.cursor, first.filter)
+	}
+
+	got := m.Snapshot()
+	want := &session.Session{Tabs: s.Tabs[:2], Active: 1, Language: "ja", History: s.History}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("snapshot = %+v\nwant %+v", got, want)
+	}
+}
+
+func TestStatusBarFitsWidth(t *testing.T) {
+	page := &search.Page{PageNum: 2, HasMore: true, Results: []search.Result{{Title: "Go", URL: "https://go.dev/"}}}
+	tests := []struct {
+		width  int
+		notice string
+		shown  bool // whether the notice fits
+		help   bool
+	}{
+		{120, "", false, true},
+		{120, "Session saved: work", true, true},
+		// The notice takes the room of the key hints
+		{60, "Session saved: work", true, false},
+		{30, "Index: database is locked", false, false},
+		{30, "", false, false},
+	}
+	for _, tt := range tests {
+		m := NewModel("", suggestBackend{}, Options{})
+		next, _ := m.Update(tea.WindowSizeMsg{Width: tt.width, Height: 30})
+		next, _ = next.(Model).Update(searchResultMsg{tab: m.id, page: page})
+		m = next.(Model)
+		m.notice = tt.notice
+
+		lines := strings.Split(m.View(), "\n")
+		status := lines[len(lines)-1]
+		if w := lipgloss.Width(status); w > tt.width {
+			t.Errorf("width %d, notice %q: status bar is %d cells: %q", tt.width, tt.notice, w, status)
+		}
+		if tt.shown && !strings.Contains(status, tt.notice) {
+			t.Errorf("width %d: notice %q missing from %q", tt.width, tt.notice, status)
+		}
+		if got := strings.Contains(status, "move"); got != tt.help {
+			t.Errorf("width %d, notice %q: key hints shown %v, want %v", tt.width, tt.notice, got, tt.help)
+		}
+	}
+}
@@ -1,39 +1,335 @@
 package main
 
 import (
+	"cmp"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/frort/ksk/internal/bang"
+	"github.com/frort/ksk/internal/browser"
+	"github.com/frort/ksk/internal/config"
+	"github.com/frort/ksk/internal/index"
 	"github.com/frort/ksk/internal/search"
+	"github.com/frort/ksk/internal/session"
 	"github.com/frort/ksk/internal/tui"
 )
 
 func main() {
-	engine := flag.String("e", "duckduckgo", "search engine (duckduckgo, brave)")
-	region := flag.String("r", "", "region/country code (e.g. jp, us, de)")
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := registerScrapers(cfg.Scrapers); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	// e and E cycle through the web engines, not plugins or the local index
+	webEngines := search.Engines()
+	if err := registerPlugins(cfg.Plugins); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	for engine, endpoint := range cfg.SuggestEndpoints {
+		if err := search.SetSuggestEndpoint(engine, endpoint); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: config: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	idx, err := openIndex(cfg.Index)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(os.Args) > 1 {
+		var run func() error
+		switch os.Args[1] {
+		case "bangs":
+			run = func() error { return runBangs(os.Args[2:]) }
+		case "serve":
+			run = func() error { return runServe(cfg, webEngines, os.Args[2:]) }
+		case "regions":
+			run = func() error { return runRegions(os.Args[2:]) }
+		}
+		if run != nil {
+			if err := run(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	bangs, err := bang.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	engine := flag.String("e", cmp.Or(cfg.Engine, "duckduckgo"), "search engine ("+strings.Join(search.Engines(), ", ")+")")
+	region := flag.String("r", cfg.Region, "region/country code (e.g. jp, us, de; see ksk regions)")
+	language := flag.String("l", cfg.Language, "result language as a BCP 47 tag (e.g. ja, pt-BR)")
+	showAds := flag.Bool("ads", cfg.ShowAds, "show sponsored results (dimmed)")
+	sessionName := flag.String("session", "", "restore the named session and save it on exit")
+	compare := flag.String("compare", "", "comma-separated engines to compare side by side, e.g. ddg,brave")
+	themeName := flag.String("theme", cfg.Theme, "color theme ("+strings.Join(tui.ThemeNames(), ", ")+" or one from the config)")
 	flag.Parse()
 
-	var backend search.Backend
-	switch *engine {
-	case "duckduckgo", "ddg":
-		backend = &search.DuckDuckGo{Region: *region}
-	case "brave", "b":
-		backend = &search.Brave{Region: *region}
-	default:
-		fmt.Fprintf(os.Stderr, "Unknown engine: %s (use duckduckgo or brave)\n", *engine)
+	theme, err := loadTheme(cfg, *themeName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	tui.SetTheme(theme, cfg.Compact)
+	keys, err := newKeyMap(cfg.Keys)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
 	query := strings.Join(flag.Args(), " ")
 
-	m := tui.NewModel(query, backend)
-	p := tea.NewProgram(m, tea.WithAltScreen())
+	var safe search.SafeSearch
+	if cfg.SafeSearch != "" {
+		if safe, err = search.ParseSafeSearch(cfg.SafeSearch); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: config: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *compare != "" {
+		if err := runCompare(cfg, keys, *compare, search.Options{Region: *region, Language: *language, SafeSearch: safe}, *showAds, query, idx); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	sess, err := loadSession(*sessionName, cfg.ResumeSession, query)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if sess != nil && query == "" {
+		// The session's settings win unless given explicitly
+		if t := sess.ActiveTab(); t != nil {
+			if !flagSet("e") {
+				*engine = t.Engine
+			}
+			if !flagSet("r") {
+				*region = t.Region
+			}
+			safe = t.SafeSearch
+		}
+		if !flagSet("l") && sess.Language != "" {
+			*language = sess.Language
+		}
+	}
+
+	// A bang in the initial query opens the site directly or picks the engine
+	if b, rest, ok := bangs.Resolve(query); ok {
+		if b.Engine == "" {
+			if err := browser.Open(b.Expand(rest)); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		*engine = b.Engine
+		query = rest
+	}
+
+	backend, err := search.New(*engine, search.Options{Region: *region, Language: *language, SafeSearch: safe})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var recorder tui.Recorder
+	if idx != nil {
+		recorder = &index.Recorder{Index: idx, FetchPages: cfg.Index.FetchPages}
+	}
 
-	if _, err := p.Run(); err != nil {
+	m := tui.NewModel(query, backend, tui.Options{
+		Sanitizer:    newSanitizer(cfg.CleanURLs),
+		ShowAds:      *showAds,
+		SnippetLines: cfg.SnippetLines,
+		ListLayout:   cfg.ListLayout,
+		Keys:         keys,
+		CharLimit:    cfg.CharLimit,
+		Editor:       cfg.Editor,
+		Engines:      webEngines,
+		Region:       *region,
+		Language:     *language,
+		SafeSearch:   safe,
+		Bangs:        bangs,
+		Recorder:     recorder,
+		Session:      sess,
+		SessionName:  cmp.Or(*sessionName, session.LastName),
+	})
+	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithMouseCellMotion())
+
+	final, err := p.Run()
+	closeIndex(idx)
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
+
+	if *sessionName != "" || cfg.ResumeSession {
+		if fm, ok := final.(tui.Model); ok {
+			if s := fm.Snapshot(); !s.Empty() {
+				if err := session.Save(cmp.Or(*sessionName, session.LastName), s); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: saving session: %v\n", err)
+					os.Exit(1)
+				}
+			}
+		}
+	}
+}
+
+// closeIndex saves what the index has not written yet.
+func closeIndex(idx *index.Index) {
+	if idx == nil {
+		return
+	}
+	if err := idx.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: saving index: %v\n", err)
+	}
+}
+
+// loadSession returns the session to restore: the named one, or the last
+// one when resume is enabled and no query was given. A session that was
+// never saved starts fresh.
+func loadSession(name string, resume bool, query string) (*session.Session, error) {
+	if name == "" {
+		if !resume || query != "" {
+			return nil, nil
+		}
+		name = session.LastName
+	}
+	s, err := session.Load(name)
+	if errors.Is(err, session.ErrNotFound) {
+		return nil, nil
+	}
+	return s, err
+}
+
+// flagSet reports whether the named flag was given on the command line.
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// newKeyMap applies the configured key bindings to the defaults.
+func newKeyMap(keys map[string][]string) (*tui.KeyMap, error) {
+	km := tui.DefaultKeyMap()
+	if err := km.Rebind(keys); err != nil {
+		return nil, fmt.Errorf("config: keys: %w", err)
+	}
+	return &km, nil
+}
+
+func newSanitizer(c config.CleanURLs) *search.Sanitizer {
+	if c.Disable {
+		return nil
+	}
+	s := search.NewSanitizer()
+	if c.StripParams != nil {
+		s.StripParams = c.StripParams
+	}
+	s.StripParams = append(s.StripParams[:len(s.StripParams):len(s.StripParams)], c.ExtraStripParams...)
+	s.Frontends = c.Frontends
+	return s
+}
+
+// openIndex opens the local index and registers it as the "local" engine.
+// It returns nil when the index is disabled. The file is only read once the
+// index is used.
+func openIndex(c config.Index) (*index.Index, error) {
+	if c.Disable {
+		return nil, nil
+	}
+	path, err := index.Path()
+	if err != nil {
+		return nil, err
+	}
+	idx := index.Open(path)
+	if c.RetentionDays > 0 {
+		idx.Retention = time.Duration(c.RetentionDays) * 24 * time.Hour
+	}
+	if c.MaxEntries > 0 {
+		idx.MaxEntries = c.MaxEntries
+	}
+	search.Register(index.EngineName, nil, func(search.Options) (search.Backend, error) {
+		return &index.Backend{Index: idx}, nil
+	})
+	return idx, nil
+}
+
+// registerScrapers makes each configured scraper selectable with -e. The
+// definitions are validated up front so mistakes surface at startup.
+func registerScrapers(scrapers []search.ScraperConfig) error {
+	for _, c := range scrapers {
+		s, err := search.NewScraper(c, search.Options{})
+		if err != nil {
+			return err
+		}
+		for _, name := range append([]string{c.Name}, c.Aliases...) {
+			if search.Registered(name) || name == index.EngineName {
+				return fmt.Errorf("scraper %s: %s is already an engine name", c.Name, name)
+			}
+		}
+		search.RegisterRegional(c.Name, c.Aliases, s.SupportedRegions(), func(o search.Options) (search.Backend, error) {
+			return search.NewScraper(c, o)
+		})
+	}
+	return nil
+}
+
+// registerPlugins makes each configured plugin selectable with -e.
+func registerPlugins(plugins []config.Plugin) error {
+	for _, p := range plugins {
+		if p.Name == "" || p.Command == "" {
+			return fmt.Errorf("plugin needs a name and a command")
+		}
+		var timeout time.Duration
+		if p.Timeout != "" {
+			d, err := time.ParseDuration(p.Timeout)
+			if err != nil {
+				return fmt.Errorf("plugin %s: invalid timeout: %w", p.Name, err)
+			}
+			timeout = d
+		}
+		for _, name := range append([]string{p.Name}, p.Aliases...) {
+			if search.Registered(name) || name == index.EngineName {
+				return fmt.Errorf("plugin %s: %s is already an engine name", p.Name, name)
+			}
+		}
+		search.Register(p.Name, p.Aliases, func(o search.Options) (search.Backend, error) {
+			return &search.ExecBackend{
+				EngineName: p.Name,
+				Command:    p.Command,
+				Args:       p.Args,
+				Timeout:    timeout,
+				Region:     o.Region,
+				Language:   o.Language,
+				Options:    p.Options,
+			}, nil
+		})
+	}
+	return nil
 }
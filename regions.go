@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/frort/ksk/internal/search"
+)
+
+// runRegions implements "ksk regions [ENGINE]".
+func runRegions(args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("usage: ksk regions [ENGINE]")
+	}
+
+	// Engines without a region list take any code, or ignore it
+	restricted := map[string][]string{}
+	var open []string
+	for _, name := range search.Engines() {
+		_, codes, err := search.EngineRegions(name)
+		if err != nil {
+			return err
+		}
+		if len(codes) == 0 {
+			open = append(open, name)
+			continue
+		}
+		restricted[name] = codes
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	if len(args) == 1 {
+		name, codes, err := search.EngineRegions(args[0])
+		if err != nil {
+			return err
+		}
+		if len(codes) == 0 {
+			fmt.Printf("%s accepts any region\n", name)
+			return nil
+		}
+		for _, r := range search.Regions() {
+			if slices.Contains(codes, r.Code) {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", r.Code, r.Name, r.Language)
+			}
+		}
+		return w.Flush()
+	}
+
+	names := make([]string, 0, len(restricted))
+	for name := range restricted {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	fmt.Fprintln(w, "CODE\tREGION\tLANGUAGE\tENGINES")
+	for _, r := range search.Regions() {
+		var engines []string
+		for _, name := range names {
+			if slices.Contains(restricted[name], r.Code) {
+				engines = append(engines, name)
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Code, r.Name, r.Language, strings.Join(engines, ", "))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if len(open) > 0 {
+		fmt.Printf("\nAny region is accepted by %s.\n", strings.Join(open, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"cmp"
+	"flag"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/frort/ksk/internal/config"
+	"github.com/frort/ksk/internal/index"
+	"github.com/frort/ksk/internal/search"
+	"github.com/frort/ksk/internal/server"
+)
+
+// runServe implements "ksk serve". Only webEngines are served unless the
+// local index or the plugins are enabled explicitly: the index holds the
+// user's history and plugins run arbitrary programs.
+func runServe(cfg *config.Config, webEngines []string, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("addr", "127.0.0.1:8080", "listen address")
+	engine := fs.String("e", cfg.Engine, "default search engine")
+	region := fs.String("r", cfg.Region, "default region/country code")
+	language := fs.String("l", cfg.Language, "default language as a BCP 47 tag")
+	serveLocal := fs.Bool("local", false, "serve the local index as the local engine")
+	servePlugins := fs.Bool("plugins", false, "serve the plugin engines")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var safe search.SafeSearch
+	if cfg.SafeSearch != "" {
+		s, err := search.ParseSafeSearch(cfg.SafeSearch)
+		if err != nil {
+			return fmt.Errorf("config: %w", err)
+		}
+		safe = s
+	}
+
+	engines := slices.Clone(webEngines)
+	if *serveLocal {
+		if !search.Registered(index.EngineName) {
+			return fmt.Errorf("the local index is disabled")
+		}
+		engines = append(engines, index.EngineName)
+	}
+	if *servePlugins {
+		for _, p := range cfg.Plugins {
+			engines = append(engines, p.Name)
+		}
+	}
+
+	name, _, err := search.EngineRegions(cmp.Or(*engine, "duckduckgo"))
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(engines, name) {
+		return fmt.Errorf("default engine %s is not served (see -local and -plugins)", name)
+	}
+	if _, err := search.New(name, search.Options{Region: *region, Language: *language, SafeSearch: safe}); err != nil {
+		return err
+	}
+
+	srv := &server.Server{
+		Engine:     name,
+		Region:     *region,
+		Language:   *language,
+		SafeSearch: safe,
+		Engines:    engines,
+		Sanitizer:  newSanitizer(cfg.CleanURLs),
+	}
+	hs := &http.Server{
+		Addr:              *addr,
+		Handler:           srv.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	fmt.Printf("ksk serving on http://%s (engine: %s)\n", *addr, name)
+	return hs.ListenAndServe()
+}
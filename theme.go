@@ -0,0 +1,56 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/frort/ksk/internal/config"
+	"github.com/frort/ksk/internal/tui"
+)
+
+var hexColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// loadTheme resolves the theme called name, built in or from the config.
+// NO_COLOR (https://no-color.org) always selects the mono theme.
+func loadTheme(cfg *config.Config, name string) (tui.Theme, error) {
+	if os.Getenv("NO_COLOR") != "" {
+		return tui.LookupTheme("mono")
+	}
+	name = cmp.Or(name, "auto")
+	ut, ok := cfg.Themes[name]
+	if !ok {
+		return tui.LookupTheme(name)
+	}
+
+	t, err := tui.LookupTheme(cmp.Or(ut.Base, "dark"))
+	if err != nil {
+		return tui.Theme{}, fmt.Errorf("theme %s: %w", name, err)
+	}
+	for _, c := range []struct {
+		dst   *lipgloss.TerminalColor
+		key   string
+		value string
+	}{
+		{&t.Primary, "primary", ut.Primary},
+		{&t.Highlight, "highlight", ut.Highlight},
+		{&t.Muted, "muted", ut.Muted},
+		{&t.URL, "url", ut.URL},
+		{&t.Snippet, "snippet", ut.Snippet},
+		{&t.Error, "error", ut.Error},
+		{&t.Border, "border", ut.Border},
+		{&t.ActiveBg, "selection", ut.Selection},
+	} {
+		if c.value == "" {
+			continue
+		}
+		if n, err := strconv.Atoi(c.value); !hexColor.MatchString(c.value) && (err != nil || n < 0 || n > 255) {
+			return tui.Theme{}, fmt.Errorf("theme %s: %s: want #rrggbb or 0-255, got %q", name, c.key, c.value)
+		}
+		*c.dst = lipgloss.Color(c.value)
+	}
+	return t, nil
+}